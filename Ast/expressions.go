@@ -35,6 +35,7 @@ func (g GroupingExpr) isExpr() {}
 
 type LiteralExpr struct {
 	Value any
+	Token *Tokens.Token // nil for literals made up by the parser
 }
 
 func (l LiteralExpr) isExpr() {}
@@ -59,6 +60,25 @@ type AssignExpr struct {
 
 func (a *AssignExpr) isExpr() {}
 
+// name op= value, like name = name op value but the variable is only looked
+// up once
+type CompoundAssignExpr struct {
+	Name     *Tokens.Token
+	Operator *Tokens.Token // PLUS_EQUAL, MINUS_EQUAL, STAR_EQUAL, SLASH_EQUAL or PERCENT_EQUAL
+	Value    Expr
+}
+
+func (a *CompoundAssignExpr) isExpr() {}
+
+// ++name and --name give the new value, name++ and name-- the old one
+type IncrementExpr struct {
+	Name     *Tokens.Token
+	Operator *Tokens.Token // PLUS_PLUS or MINUS_MINUS
+	IsPrefix bool
+}
+
+func (e *IncrementExpr) isExpr() {}
+
 type LogicalExpr struct {
 	Left     Expr
 	Operator *Tokens.Token
@@ -71,13 +91,64 @@ type Call struct {
 	Callee    Expr
 	Paren     *Tokens.Token
 	Arguments []Expr
+	// one optional name per argument passed as name: value, nil when every
+	// argument is positional
+	Names []*Tokens.Token
 }
 
 func (c *Call) isExpr() {}
 
+// "a ${x} b", Parts alternate between string literals and the embedded
+// expressions, starting and ending with a literal
+type InterpolationExpr struct {
+	Parts []Expr
+}
+
+func (i *InterpolationExpr) isExpr() {}
+
+// spawn f(args) makes the call on a task of its own and evaluates to the task
+type SpawnExpr struct {
+	Keyword *Tokens.Token
+	Call    *Call
+}
+
+func (s *SpawnExpr) isExpr() {}
+
+// await value waits for a future to settle and evaluates to its value
+type AwaitExpr struct {
+	Keyword *Tokens.Token
+	Value   Expr
+}
+
+func (a *AwaitExpr) isExpr() {}
+
+// the expression form of a match, each case gives a value
+type MatchExpr struct {
+	Keyword *Tokens.Token
+	Value   Expr
+	Cases   []*MatchCase
+	Default Expr // nil without a default case
+}
+
+func (m *MatchExpr) isExpr() {}
+
+// object.name, only generators and futures have properties
+type GetExpr struct {
+	Object Expr
+	Name   *Tokens.Token
+}
+
+func (g *GetExpr) isExpr() {}
+
 type AnonymousFuncion struct {
-	Params []*Tokens.Token
-	Body   []Stmt
+	Params      []*Tokens.Token
+	ParamTypes  []*Tokens.Token // one optional annotation per param
+	Defaults    []Expr          // one optional default value per param
+	ReturnType  *Tokens.Token
+	Body        []Stmt
+	IsGenerator bool
+	IsAsync     bool
+	IsVariadic  bool
 }
 
 func (f AnonymousFuncion) isExpr() {}
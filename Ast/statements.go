@@ -14,6 +14,7 @@ type ExpressionStmt struct {
 func (expr ExpressionStmt) stmt() {}
 
 type PrintStmt struct {
+	Keyword    *Tokens.Token
 	Expression Expr
 }
 
@@ -21,18 +22,22 @@ func (expr PrintStmt) stmt() {}
 
 type VarStmt struct {
 	Name        *Tokens.Token
+	Type        *Tokens.Token // optional annotation, only used by the checker
 	Initializer Expr
+	IsConst     bool // declared with const or let, it can't be assigned
 }
 
 func (expr VarStmt) stmt() {}
 
 type BlockStmt struct {
+	Brace      *Tokens.Token // nil for blocks made up by the parser
 	Statements []Stmt
 }
 
 func (b BlockStmt) stmt() {}
 
 type IfStmt struct {
+	Keyword    *Tokens.Token
 	Condition  Expr
 	ThenBranch Stmt
 	ElseBranch Stmt
@@ -41,20 +46,99 @@ type IfStmt struct {
 func (ifs IfStmt) stmt() {}
 
 type WhileStmt struct {
-	Condition Expr
+	Keyword   *Tokens.Token // `while`, or `for` when desugared
+	Condition Expr          // nil loops forever
 	Body      Stmt
 }
 
 func (while WhileStmt) stmt() {}
 
 type NamedFunction struct {
-	Name   *Tokens.Token
-	Params []*Tokens.Token
-	Body   []Stmt
+	Name        *Tokens.Token
+	Params      []*Tokens.Token
+	ParamTypes  []*Tokens.Token // one optional annotation per param
+	Defaults    []Expr          // one optional default value per param
+	ReturnType  *Tokens.Token
+	Body        []Stmt
+	IsGenerator bool // the body yields, calls return a generator
+	IsAsync     bool // declared with async, calls return a future
+	IsVariadic  bool // the last param collects the extra arguments in a list
 }
 
 func (f NamedFunction) stmt() {}
 
+// test "name" { ... }, skipped unless the program is run by golox test -unit
+type TestStmt struct {
+	Keyword *Tokens.Token
+	Name    *Tokens.Token // the STRING token, its literal is the name
+	Body    []Stmt
+}
+
+func (t TestStmt) stmt() {}
+
+type YieldStmt struct {
+	Keyword *Tokens.Token
+	Value   Expr // nil yields nil
+}
+
+func (y YieldStmt) stmt() {}
+
+// select { case v = recv(ch) => ... case send(ch, x) => ... default => ... }
+// runs the first case whose channel operation can go ahead, waiting for one
+// unless there is a default
+type SelectStmt struct {
+	Keyword *Tokens.Token
+	Cases   []*SelectCase
+	Default Stmt // nil without a default case
+}
+
+func (s SelectStmt) stmt() {}
+
+type SelectCase struct {
+	Keyword *Tokens.Token
+	Name    *Tokens.Token // the variable a recv case assigns to, nil if none
+	Op      *Tokens.Token // `recv` or `send`
+	Channel Expr
+	Value   Expr // what a send case sends, nil for recv
+	Body    Stmt
+}
+
+// match (value) { case 1, 2 => ... case n if n > 10 => ... default => ... }
+// runs the first case with a pattern that matches the value and a guard
+// that holds, or the default when none does
+type MatchStmt struct {
+	Keyword *Tokens.Token
+	Value   Expr
+	Cases   []*MatchCase
+	Default Stmt // nil without a default case
+}
+
+func (m MatchStmt) stmt() {}
+
+// A case of a match statement or expression. A case with a binding
+// pattern has only that pattern
+type MatchCase struct {
+	Keyword  *Tokens.Token
+	Patterns []*Pattern
+	Guard    Expr // nil without `if`
+	Body     Stmt // in a match statement
+	Result   Expr // in a match expression
+}
+
+type PatternKind int
+
+const (
+	LiteralPattern  PatternKind = iota // equal to a literal
+	BindingPattern                     // anything, bound to a name
+	WildcardPattern                    // anything, written _
+)
+
+type Pattern struct {
+	Kind  PatternKind
+	Token *Tokens.Token // the literal, the name or _, the '-' of a negative number
+	Value any           // of a literal pattern
+}
+
 type Return struct {
 	Keyword *Tokens.Token
 	Value   Expr
@@ -0,0 +1,212 @@
+package Ast
+
+// Returns the direct children of a node, or of a []Stmt, in source order
+func Children(node Node) []Node {
+	children := []Node{}
+	add := func(nodes ...Node) {
+		for _, n := range nodes {
+			if n != nil {
+				children = append(children, n)
+			}
+		}
+	}
+	addStmts := func(stmts []Stmt) {
+		for _, stmt := range stmts {
+			add(stmt)
+		}
+	}
+	addExprs := func(exprs []Expr) {
+		for _, expr := range exprs {
+			add(expr)
+		}
+	}
+
+	switch n := node.(type) {
+	case []Stmt:
+		addStmts(n)
+	case *ExpressionStmt:
+		add(n.Expression)
+	case *PrintStmt:
+		add(n.Expression)
+	case *VarStmt:
+		add(n.Initializer)
+	case *BlockStmt:
+		addStmts(n.Statements)
+	case *IfStmt:
+		add(n.Condition, n.ThenBranch, n.ElseBranch)
+	case *WhileStmt:
+		add(n.Condition, n.Body)
+	case *NamedFunction:
+		addExprs(n.Defaults)
+		addStmts(n.Body)
+	case *TestStmt:
+		addStmts(n.Body)
+	case *Return:
+		add(n.Value)
+	case *YieldStmt:
+		add(n.Value)
+	case *SelectStmt:
+		for _, c := range n.Cases {
+			add(c.Channel, c.Value, c.Body)
+		}
+		add(n.Default)
+	case *MatchStmt:
+		add(n.Value)
+		for _, c := range n.Cases {
+			add(c.Guard, c.Body)
+		}
+		add(n.Default)
+	case *MatchExpr:
+		add(n.Value)
+		for _, c := range n.Cases {
+			add(c.Guard, c.Result)
+		}
+		add(n.Default)
+	case *ConditionalExpr:
+		add(n.Condition, n.Then, n.Else)
+	case *BinaryExpr:
+		add(n.Left, n.Right)
+	case *LogicalExpr:
+		add(n.Left, n.Right)
+	case *GroupingExpr:
+		add(n.Expression)
+	case *UnaryExpr:
+		add(n.Right)
+	case *AssignExpr:
+		add(n.Value)
+	case *GetExpr:
+		add(n.Object)
+	case *SpawnExpr:
+		add(n.Call)
+	case *AwaitExpr:
+		add(n.Value)
+	case *CompoundAssignExpr:
+		add(n.Value)
+	case *Call:
+		add(n.Callee)
+		addExprs(n.Arguments)
+	case *InterpolationExpr:
+		addExprs(n.Parts)
+	case *AnonymousFuncion:
+		addExprs(n.Defaults)
+		addStmts(n.Body)
+	}
+	return children
+}
+
+// Calls f for node and then its descendants, depth first. The children of a
+// node are skipped when f returns false for it
+func Inspect(node Node, f func(Node) bool) {
+	if !f(node) {
+		return
+	}
+	for _, child := range Children(node) {
+		Inspect(child, f)
+	}
+}
+
+// Returns the tokens held by the node itself, not by its children
+func OwnTokens(node Node) []*Token {
+	tokens := []*Token{}
+	add := func(ts ...*Token) {
+		for _, t := range ts {
+			if t != nil {
+				tokens = append(tokens, t)
+			}
+		}
+	}
+	addCases := func(cases []*MatchCase) {
+		for _, c := range cases {
+			add(c.Keyword)
+			for _, pattern := range c.Patterns {
+				add(pattern.Token)
+			}
+		}
+	}
+
+	switch n := node.(type) {
+	case *PrintStmt:
+		add(n.Keyword)
+	case *BlockStmt:
+		add(n.Brace)
+	case *IfStmt:
+		add(n.Keyword)
+	case *WhileStmt:
+		add(n.Keyword)
+	case *LiteralExpr:
+		add(n.Token)
+	case *VarStmt:
+		add(n.Name, n.Type)
+	case *NamedFunction:
+		add(n.Name)
+		add(n.Params...)
+		add(n.ParamTypes...)
+		add(n.ReturnType)
+	case *TestStmt:
+		add(n.Keyword, n.Name)
+	case *Return:
+		add(n.Keyword)
+	case *YieldStmt:
+		add(n.Keyword)
+	case *SelectStmt:
+		add(n.Keyword)
+		for _, c := range n.Cases {
+			add(c.Keyword, c.Name, c.Op)
+		}
+	case *SpawnExpr:
+		add(n.Keyword)
+	case *MatchStmt:
+		add(n.Keyword)
+		addCases(n.Cases)
+	case *MatchExpr:
+		add(n.Keyword)
+		addCases(n.Cases)
+	case *AwaitExpr:
+		add(n.Keyword)
+	case *GetExpr:
+		add(n.Name)
+	case *BinaryExpr:
+		add(n.Operator)
+	case *LogicalExpr:
+		add(n.Operator)
+	case *UnaryExpr:
+		add(n.Operator)
+	case *VariableExpr:
+		add(n.Name)
+	case *AssignExpr:
+		add(n.Name)
+	case *CompoundAssignExpr:
+		add(n.Name, n.Operator)
+	case *IncrementExpr:
+		add(n.Name, n.Operator)
+	case *Call:
+		add(n.Paren)
+		add(n.Names...)
+	case *AnonymousFuncion:
+		add(n.Params...)
+		add(n.ParamTypes...)
+		add(n.ReturnType)
+	}
+	return tokens
+}
+
+// Returns the first and last token found anywhere in the node, both are nil
+// for nodes without tokens such as literals made up by the parser
+func Span(node Node) (start *Token, end *Token) {
+	Inspect(node, func(n Node) bool {
+		for _, token := range OwnTokens(n) {
+			if start == nil || before(token, start) {
+				start = token
+			}
+			if end == nil || before(end, token) {
+				end = token
+			}
+		}
+		return true
+	})
+	return start, end
+}
+
+func before(a *Token, b *Token) bool {
+	return a.Line < b.Line || (a.Line == b.Line && a.Column < b.Column)
+}
@@ -0,0 +1,461 @@
+package Checker
+
+import (
+	"fmt"
+
+	"github.com/AnshVM/golox/Ast"
+	"github.com/AnshVM/golox/Error"
+	"github.com/AnshVM/golox/Tokens"
+)
+
+// Signatures of the native functions defined by the interpreter
+var natives = map[string]Type{
+	"clock":   &Function{Params: []Type{}, Result: Any},
+	"assert":  &Function{Params: []Type{Any, Any}, Optional: 1, Result: Nil},
+	"len":     &Function{Params: []Type{Any}, Result: Number}, // a string or a list
+	"charAt":  &Function{Params: []Type{String, Number}, Result: String},
+	"substr":  &Function{Params: []Type{String, Number, Number}, Result: String},
+	"indexOf": &Function{Params: []Type{String, String}, Result: Number},
+	"list":    &Function{Params: []Type{Any}, Variadic: true, Result: Any},
+	"at":      &Function{Params: []Type{Any, Number}, Result: Any},
+	"chan":    &Function{Params: []Type{}, Result: Any},
+	"send":    &Function{Params: []Type{Any, Any}, Result: Nil},
+	"recv":    &Function{Params: []Type{Any}, Result: Any},
+	"close":   &Function{Params: []Type{Any}, Result: Nil},
+	"wait":    &Function{Params: []Type{Any}, Result: Any},
+
+	"setTimeout":    &Function{Params: []Type{Any, Number}, Result: Number},
+	"setInterval":   &Function{Params: []Type{Any, Number}, Result: Number},
+	"clearTimeout":  &Function{Params: []Type{Any}, Result: Nil},
+	"clearInterval": &Function{Params: []Type{Any}, Result: Nil},
+	"future":        &Function{Params: []Type{}, Result: Any},
+	"sleep":         &Function{Params: []Type{Number}, Result: Any},
+}
+
+// Checker reports type errors before a program runs. Variables and params
+// without an annotation are `any` and never cause errors, so unannotated code
+// only gets checked where types are obvious, like literals and calls to
+// functions declared with `fun`
+type Checker struct {
+	scopes []map[string]Type
+	// declared result of the function being checked, nil at the top level.
+	// For a generator it is the type of the values it yields
+	result    Type
+	generator bool
+}
+
+func NewChecker() *Checker {
+	globals := map[string]Type{}
+	for name, t := range natives {
+		globals[name] = t
+	}
+	return &Checker{scopes: []map[string]Type{globals}}
+}
+
+func (c *Checker) Check(stmts []Ast.Stmt) {
+	for _, stmt := range stmts {
+		c.stmt(stmt)
+	}
+}
+
+func (c *Checker) stmt(stmt Ast.Stmt) {
+	switch s := stmt.(type) {
+	case *Ast.ExpressionStmt:
+		c.expr(s.Expression)
+	case *Ast.PrintStmt:
+		c.expr(s.Expression)
+	case *Ast.VarStmt:
+		declared := c.annotation(s.Type)
+		if s.Initializer != nil {
+			value := c.expr(s.Initializer)
+			if !assignable(value, declared) {
+				c.error(s.Name, fmt.Sprintf("Cannot initialize '%s' of type %s with %s.", s.Name.Lexeme, declared, value))
+			}
+		}
+		c.define(s.Name.Lexeme, declared)
+	case *Ast.BlockStmt:
+		c.beginScope()
+		c.Check(s.Statements)
+		c.endScope()
+	case *Ast.IfStmt:
+		c.expr(s.Condition)
+		c.stmt(s.ThenBranch)
+		if s.ElseBranch != nil {
+			c.stmt(s.ElseBranch)
+		}
+	case *Ast.WhileStmt:
+		c.expr(s.Condition)
+		c.stmt(s.Body)
+	case *Ast.NamedFunction:
+		signature := c.signature(s.Params, s.ParamTypes, s.Defaults, s.IsVariadic, s.ReturnType)
+		c.define(s.Name.Lexeme, callType(signature, s.IsGenerator || s.IsAsync))
+		c.function(s.Params, s.Defaults, signature, s.IsGenerator, s.Body)
+	case *Ast.TestStmt:
+		c.beginScope()
+		c.Check(s.Body)
+		c.endScope()
+	case *Ast.Return:
+		value := Type(Nil)
+		if s.Value != nil {
+			value = c.expr(s.Value)
+		}
+		if c.result != nil && !c.generator && !assignable(value, c.result) {
+			c.error(s.Keyword, fmt.Sprintf("Cannot return %s from a function returning %s.", value, c.result))
+		}
+	case *Ast.MatchStmt:
+		value := c.expr(s.Value)
+		for _, cs := range s.Cases {
+			c.matchCase(cs, value, func() {
+				c.stmt(cs.Body)
+			})
+		}
+		if s.Default != nil {
+			c.stmt(s.Default)
+		}
+	case *Ast.SelectStmt:
+		for _, cs := range s.Cases {
+			c.expr(cs.Channel)
+			if cs.Value != nil {
+				c.expr(cs.Value)
+			}
+		}
+		for _, cs := range s.Cases {
+			c.beginScope()
+			if cs.Name != nil {
+				c.define(cs.Name.Lexeme, Any)
+			}
+			c.stmt(cs.Body)
+			c.endScope()
+		}
+		if s.Default != nil {
+			c.stmt(s.Default)
+		}
+	case *Ast.YieldStmt:
+		value := Type(Nil)
+		if s.Value != nil {
+			value = c.expr(s.Value)
+		}
+		if c.result != nil && !assignable(value, c.result) {
+			c.error(s.Keyword, fmt.Sprintf("Cannot yield %s from a generator of %s.", value, c.result))
+		}
+	}
+}
+
+func (c *Checker) expr(expr Ast.Expr) Type {
+	switch e := expr.(type) {
+	case *Ast.LiteralExpr:
+		switch e.Value.(type) {
+		case float32:
+			return Number
+		case string:
+			return String
+		case bool:
+			return Bool
+		case nil:
+			return Nil
+		}
+		return Any
+	case *Ast.GroupingExpr:
+		return c.expr(e.Expression)
+	case *Ast.VariableExpr:
+		return c.lookup(e.Name.Lexeme)
+	case *Ast.AssignExpr:
+		value := c.expr(e.Value)
+		declared := c.lookup(e.Name.Lexeme)
+		if !assignable(value, declared) {
+			c.error(e.Name, fmt.Sprintf("Cannot assign %s to '%s' of type %s.", value, e.Name.Lexeme, declared))
+		}
+		return value
+	case *Ast.CompoundAssignExpr:
+		declared := c.lookup(e.Name.Lexeme)
+		value := c.expr(e.Value)
+		result := c.operation(e.Operator, Tokens.CompoundOperators[e.Operator.Type], declared, value)
+		if !assignable(result, declared) {
+			c.error(e.Name, fmt.Sprintf("Cannot assign %s to '%s' of type %s.", result, e.Name.Lexeme, declared))
+		}
+		return result
+	case *Ast.IncrementExpr:
+		c.expectNumber(e.Operator, c.lookup(e.Name.Lexeme))
+		return Number
+	case *Ast.UnaryExpr:
+		right := c.expr(e.Right)
+		if e.Operator.Type == Tokens.BANG {
+			return Bool
+		}
+		c.expectNumber(e.Operator, right)
+		return Number
+	case *Ast.BinaryExpr:
+		return c.binary(e)
+	case *Ast.LogicalExpr:
+		return join(c.expr(e.Left), c.expr(e.Right))
+	case *Ast.ConditionalExpr:
+		c.expr(e.Condition)
+		return join(c.expr(e.Then), c.expr(e.Else))
+	case *Ast.Call:
+		return c.call(e)
+	case *Ast.GetExpr:
+		c.expr(e.Object)
+		return Any
+	case *Ast.SpawnExpr:
+		// the call is checked, what spawn gives is a task
+		c.call(e.Call)
+		return Any
+	case *Ast.AwaitExpr:
+		c.expr(e.Value)
+		return Any
+	case *Ast.MatchExpr:
+		value := c.expr(e.Value)
+		var result Type
+		add := func(t Type) {
+			if result == nil {
+				result = t
+			} else {
+				result = join(result, t)
+			}
+		}
+		for _, cs := range e.Cases {
+			c.matchCase(cs, value, func() {
+				add(c.expr(cs.Result))
+			})
+		}
+		if e.Default != nil {
+			add(c.expr(e.Default))
+		}
+		if result == nil {
+			return Any
+		}
+		return result
+	case *Ast.InterpolationExpr:
+		for _, part := range e.Parts {
+			c.expr(part)
+		}
+		return String
+	case *Ast.AnonymousFuncion:
+		signature := c.signature(e.Params, e.ParamTypes, e.Defaults, e.IsVariadic, e.ReturnType)
+		c.function(e.Params, e.Defaults, signature, e.IsGenerator, e.Body)
+		return callType(signature, e.IsGenerator || e.IsAsync)
+	}
+	return Any
+}
+
+// A binding pattern has the type of the matched value, in a scope around the
+// guard and the body
+func (c *Checker) matchCase(cs *Ast.MatchCase, value Type, body func()) {
+	c.beginScope()
+	for _, pattern := range cs.Patterns {
+		if pattern.Kind == Ast.BindingPattern {
+			c.define(pattern.Token.Lexeme, value)
+		}
+	}
+	if cs.Guard != nil {
+		c.expr(cs.Guard)
+	}
+	body()
+	c.endScope()
+}
+
+func (c *Checker) binary(e *Ast.BinaryExpr) Type {
+	left := c.expr(e.Left)
+	right := c.expr(e.Right)
+	return c.operation(e.Operator, e.Operator.Type, left, right)
+}
+
+// The type of applying the binary operator opType, errors are reported at
+// operator, which is a compound assignment for those
+func (c *Checker) operation(operator *Tokens.Token, opType string, left Type, right Type) Type {
+	switch opType {
+	case Tokens.PLUS:
+		if left == Any || right == Any {
+			if left == Number || right == Number {
+				return Number
+			}
+			if left == String || right == String {
+				return String
+			}
+			c.expectNumberOrString(operator, left, right)
+			return Any
+		}
+		if left == right && (left == Number || left == String) {
+			return left
+		}
+		c.error(operator, fmt.Sprintf("Operands of '%s' must be two numbers or two strings, got %s and %s.", operator.Lexeme, left, right))
+		return Any
+	case Tokens.MINUS, Tokens.STAR, Tokens.SLASH, Tokens.PERCENT:
+		c.expectNumber(operator, left)
+		c.expectNumber(operator, right)
+		return Number
+	case Tokens.GREATER, Tokens.GREATER_EQUAL, Tokens.LESS, Tokens.LESS_EQAUL:
+		c.expectNumber(operator, left)
+		c.expectNumber(operator, right)
+		return Bool
+	}
+	return Bool
+}
+
+func (c *Checker) call(e *Ast.Call) Type {
+	callee := c.expr(e.Callee)
+	args := []Type{}
+	for _, arg := range e.Arguments {
+		args = append(args, c.expr(arg))
+	}
+	if callee == Any {
+		return Any
+	}
+	function, ok := callee.(*Function)
+	if !ok {
+		c.error(e.Paren, fmt.Sprintf("Can only call functions, not %s.", callee))
+		return Any
+	}
+	if function.AnyArity {
+		return function.Result
+	}
+	if e.Names != nil {
+		c.namedArguments(e, function, args)
+		return function.Result
+	}
+	if message := function.arityError(len(args)); message != "" {
+		c.error(e.Paren, message)
+		return function.Result
+	}
+	for index, arg := range args {
+		if param := function.param(index); !assignable(arg, param) {
+			c.error(e.Paren, fmt.Sprintf("Argument %d must be %s, got %s.", index+1, param, arg))
+		}
+	}
+	return function.Result
+}
+
+// Checks the type of every argument by the param it binds. Which params are
+// left out is only checked when the program runs
+func (c *Checker) namedArguments(e *Ast.Call, function *Function, args []Type) {
+	if function.Names == nil {
+		c.error(e.Paren, "Only functions declared in Lox take named arguments.")
+		return
+	}
+	for index, arg := range args {
+		position := index
+		if name := e.Names[index]; name != nil {
+			position = -1
+			for at, param := range function.Names {
+				if param == name.Lexeme {
+					position = at
+				}
+			}
+			if position == -1 {
+				c.error(e.Paren, fmt.Sprintf("Unknown parameter '%s'.", name.Lexeme))
+				continue
+			}
+		}
+		if param := function.param(position); !assignable(arg, param) {
+			c.error(e.Paren, fmt.Sprintf("Argument %d must be %s, got %s.", index+1, param, arg))
+		}
+	}
+}
+
+func (c *Checker) signature(params []*Tokens.Token, paramTypes []*Tokens.Token, defaults []Ast.Expr, isVariadic bool, returnType *Tokens.Token) *Function {
+	names := []string{}
+	for _, param := range params {
+		names = append(names, param.Lexeme)
+	}
+	types := []Type{}
+	for _, annotation := range paramTypes {
+		types = append(types, c.annotation(annotation))
+	}
+	optional := 0
+	for _, value := range defaults {
+		if value != nil {
+			optional++
+		}
+	}
+	return &Function{Params: types, Names: names, Optional: optional, Variadic: isVariadic, Result: c.annotation(returnType)}
+}
+
+// calling a generator returns a generator and calling an async function
+// returns a future, neither has a type of its own
+func callType(signature *Function, returnsObject bool) *Function {
+	if returnsObject {
+		return &Function{Params: signature.Params, Names: signature.Names, Optional: signature.Optional, Variadic: signature.Variadic, Result: Any}
+	}
+	return signature
+}
+
+// A rest param holds a list, its annotation is the type of every argument
+// it collects
+func (c *Checker) function(params []*Tokens.Token, defaults []Ast.Expr, signature *Function, isGenerator bool, body []Ast.Stmt) {
+	enclosing, enclosingGenerator := c.result, c.generator
+	c.result, c.generator = signature.Result, isGenerator
+	c.beginScope()
+	for index, param := range params {
+		paramType := Type(Any)
+		if index < len(signature.Params) {
+			paramType = signature.Params[index]
+		}
+		if index < len(defaults) && defaults[index] != nil {
+			if valueType := c.expr(defaults[index]); !assignable(valueType, paramType) {
+				c.error(param, fmt.Sprintf("Default value of '%s' must be %s, got %s.", param.Lexeme, paramType, valueType))
+			}
+		}
+		if signature.Variadic && index == len(params)-1 {
+			paramType = Any
+		}
+		c.define(param.Lexeme, paramType)
+	}
+	c.Check(body)
+	c.endScope()
+	c.result, c.generator = enclosing, enclosingGenerator
+}
+
+func (c *Checker) annotation(annotation *Tokens.Token) Type {
+	t, err := fromAnnotation(annotation)
+	if err != nil {
+		c.error(annotation, err.Error())
+	}
+	return t
+}
+
+func (c *Checker) expectNumber(operator *Tokens.Token, operand Type) {
+	if !assignable(operand, Number) {
+		c.error(operator, fmt.Sprintf("Operand of '%s' must be a number, got %s.", operator.Lexeme, operand))
+	}
+}
+
+func (c *Checker) expectNumberOrString(operator *Tokens.Token, operands ...Type) {
+	for _, operand := range operands {
+		if operand != Any && operand != Number && operand != String {
+			c.error(operator, fmt.Sprintf("Operands of '+' must be two numbers or two strings, got %s.", operand))
+		}
+	}
+}
+
+func (c *Checker) error(token *Tokens.Token, message string) {
+	Error.ReportParseError(token, message)
+}
+
+func (c *Checker) lookup(name string) Type {
+	for i := len(c.scopes) - 1; i >= 0; i-- {
+		if t, ok := c.scopes[i][name]; ok {
+			return t
+		}
+	}
+	return Any
+}
+
+func (c *Checker) define(name string, t Type) {
+	c.scopes[len(c.scopes)-1][name] = t
+}
+
+func (c *Checker) beginScope() {
+	c.scopes = append(c.scopes, map[string]Type{})
+}
+
+func (c *Checker) endScope() {
+	c.scopes = c.scopes[:len(c.scopes)-1]
+}
+
+// the type of an expression that is either a or b
+func join(a Type, b Type) Type {
+	if a == b {
+		return a
+	}
+	return Any
+}
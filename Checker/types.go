@@ -0,0 +1,112 @@
+package Checker
+
+import (
+	"fmt"
+
+	"github.com/AnshVM/golox/Tokens"
+)
+
+type Type interface {
+	String() string
+}
+
+type Basic string
+
+const (
+	Any    Basic = "any"
+	Number Basic = "number"
+	String Basic = "string"
+	Bool   Basic = "bool"
+	Nil    Basic = "nil"
+)
+
+func (b Basic) String() string {
+	return string(b)
+}
+
+// A function whose signature is known, or any function when AnyArity is set
+// as for the `fun` annotation. The last Optional params have a default, and
+// the last param of a Variadic function takes any number of arguments. Names
+// are only known for functions declared in Lox
+type Function struct {
+	Params   []Type
+	Names    []string
+	Optional int
+	Variadic bool
+	Result   Type
+	AnyArity bool
+}
+
+// Returns the error for a call passing count arguments, worded like the one
+// the interpreter reports, or "" when the function takes that many
+func (f *Function) arityError(count int) string {
+	fixed := len(f.Params)
+	if f.Variadic {
+		fixed--
+	}
+	least := fixed - f.Optional
+	switch {
+	case count >= least && (count <= fixed || f.Variadic):
+		return ""
+	case least == fixed && !f.Variadic:
+		return fmt.Sprintf("Expected %d arguments, got %d", least, count)
+	case f.Variadic:
+		return fmt.Sprintf("Expected at least %d arguments, got %d", least, count)
+	default:
+		return fmt.Sprintf("Expected %d to %d arguments, got %d", least, fixed, count)
+	}
+}
+
+// The type of the argument at index, any when no param takes it
+func (f *Function) param(index int) Type {
+	switch {
+	case index < len(f.Params):
+		return f.Params[index]
+	case f.Variadic:
+		return f.Params[len(f.Params)-1]
+	}
+	return Any
+}
+
+func (f *Function) String() string {
+	return "fun"
+}
+
+// Resolves an annotation, a missing annotation means any
+func fromAnnotation(annotation *Tokens.Token) (Type, error) {
+	if annotation == nil {
+		return Any, nil
+	}
+	switch annotation.Lexeme {
+	case "any":
+		return Any, nil
+	case "number":
+		return Number, nil
+	case "string":
+		return String, nil
+	case "bool":
+		return Bool, nil
+	case "nil":
+		return Nil, nil
+	case "fun":
+		return &Function{Result: Any, AnyArity: true}, nil
+	}
+	return Any, fmt.Errorf("Unknown type '%s'.", annotation.Lexeme)
+}
+
+// Whether a value of type from can be stored where to is expected
+func assignable(from Type, to Type) bool {
+	if from == Any || to == Any {
+		return true
+	}
+	if _, ok := to.(*Function); ok {
+		_, ok := from.(*Function)
+		return ok
+	}
+	return from == to
+}
+
+func isFunction(t Type) bool {
+	_, ok := t.(*Function)
+	return ok
+}
@@ -0,0 +1,105 @@
+package Coverage
+
+import (
+	"sync"
+
+	"github.com/AnshVM/golox/Ast"
+	"github.com/AnshVM/golox/Tokens"
+)
+
+// Statement is a statement of the program and the number of times it ran
+type Statement struct {
+	Line uint // 0-based, like token lines
+	Hits int
+}
+
+// Branch is a point where the program takes one of two arms: the then and
+// else side of an if or ?:, or short-circuiting an and/or versus evaluating
+// its right side
+type Branch struct {
+	Line uint
+	Kind string    // "if", "?:", "and" or "or"
+	Arms [2]string // names of the arms, for reports
+	Hits [2]int
+}
+
+func (b *Branch) Reached() bool {
+	return b.Hits[0]+b.Hits[1] > 0
+}
+
+// Profile records what ran while interpreting a program. It implements
+// Interpreter.Tracer
+type Profile struct {
+	Statements []*Statement // in source order
+	Branches   []*Branch    // in source order
+	statements map[Ast.Stmt]*Statement
+	branches   map[Ast.Node]*Branch
+	mu         sync.Mutex // spawned tasks run statements concurrently
+}
+
+// Creates a profile for the statements and branches of program, which should
+// be the tree that gets interpreted, after the optimizer if it runs
+func New(program []Ast.Stmt) *Profile {
+	p := &Profile{statements: map[Ast.Stmt]*Statement{}, branches: map[Ast.Node]*Branch{}}
+	Ast.Inspect(program, func(node Ast.Node) bool {
+		if _, ok := node.([]Ast.Stmt); ok {
+			return true
+		}
+		start, _ := Ast.Span(node)
+		if start == nil {
+			// made up by the parser or the optimizer, like an empty loop body
+			return true
+		}
+		if stmt, ok := node.(Ast.Stmt); ok {
+			s := &Statement{Line: start.Line}
+			p.statements[stmt] = s
+			p.Statements = append(p.Statements, s)
+		}
+		if b := newBranch(node, start); b != nil {
+			p.branches[node] = b
+			p.Branches = append(p.Branches, b)
+		}
+		return true
+	})
+	return p
+}
+
+func newBranch(node Ast.Node, start *Tokens.Token) *Branch {
+	switch n := node.(type) {
+	case *Ast.IfStmt:
+		return &Branch{Line: start.Line, Kind: "if", Arms: [2]string{"then", "else"}}
+	case *Ast.ConditionalExpr:
+		return &Branch{Line: start.Line, Kind: "?:", Arms: [2]string{"then", "else"}}
+	case *Ast.LogicalExpr:
+		return &Branch{Line: n.Operator.Line, Kind: n.Operator.Lexeme, Arms: [2]string{"short-circuit", "right"}}
+	}
+	return nil
+}
+
+func (p *Profile) Statement(stmt Ast.Stmt) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if s, ok := p.statements[stmt]; ok {
+		s.Hits++
+	}
+}
+
+func (p *Profile) Branch(node Ast.Node, arm int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if b, ok := p.branches[node]; ok {
+		b.Hits[arm]++
+	}
+}
+
+// Hits per line, a line counts as many runs as the statement on it that
+// ran the most
+func (p *Profile) Lines() map[uint]int {
+	lines := map[uint]int{}
+	for _, s := range p.Statements {
+		if hits, ok := lines[s.Line]; !ok || s.Hits > hits {
+			lines[s.Line] = s.Hits
+		}
+	}
+	return lines
+}
@@ -0,0 +1,121 @@
+package Coverage
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Writes source with the number of runs of every line in front of it, lines
+// without statements have no count and ##### marks lines that never ran.
+// Branch arms that were never taken are listed after the source, followed by
+// the totals. Without source only the line numbers are written
+func (p *Profile) Report(w io.Writer, source string) {
+	lines := p.Lines()
+	texts := strings.Split(strings.TrimSuffix(source, "\n"), "\n")
+	if source == "" {
+		last := uint(0)
+		for line := range lines {
+			if line > last {
+				last = line
+			}
+		}
+		texts = make([]string, last+1)
+	}
+	for index, text := range texts {
+		count := ""
+		if hits, ok := lines[uint(index)]; ok {
+			count = fmt.Sprint(hits)
+			if hits == 0 {
+				count = "#####"
+			}
+		}
+		fmt.Fprintf(w, "%6s | %4d | %s\n", count, index+1, text)
+	}
+
+	branches := p.sortedBranches()
+	missed := false
+	for _, b := range branches {
+		for arm, hits := range b.Hits {
+			if hits > 0 {
+				continue
+			}
+			if !missed {
+				fmt.Fprintln(w, "\nBranches never taken:")
+				missed = true
+			}
+			fmt.Fprintf(w, "  line %d: %s %s\n", b.Line+1, b.Kind, b.Arms[arm])
+		}
+	}
+
+	coveredLines := 0
+	for _, hits := range lines {
+		if hits > 0 {
+			coveredLines++
+		}
+	}
+	fmt.Fprintf(w, "\nLines: %s  Branches: %s\n", percent(coveredLines, len(lines)), percent(p.takenArms(), 2*len(branches)))
+}
+
+// Writes the profile as an LCOV tracefile for the script at path
+func (p *Profile) LCOV(w io.Writer, path string) {
+	fmt.Fprintln(w, "TN:")
+	fmt.Fprintf(w, "SF:%s\n", path)
+
+	branches := p.sortedBranches()
+	for block, b := range branches {
+		for arm, hits := range b.Hits {
+			// - marks arms of a branch that was never reached
+			taken := "-"
+			if b.Reached() {
+				taken = fmt.Sprint(hits)
+			}
+			fmt.Fprintf(w, "BRDA:%d,%d,%d,%s\n", b.Line+1, block, arm, taken)
+		}
+	}
+	fmt.Fprintf(w, "BRF:%d\n", 2*len(branches))
+	fmt.Fprintf(w, "BRH:%d\n", p.takenArms())
+
+	lines := p.Lines()
+	numbers := []uint{}
+	for line := range lines {
+		numbers = append(numbers, line)
+	}
+	sort.Slice(numbers, func(a, b int) bool { return numbers[a] < numbers[b] })
+	covered := 0
+	for _, line := range numbers {
+		fmt.Fprintf(w, "DA:%d,%d\n", line+1, lines[line])
+		if lines[line] > 0 {
+			covered++
+		}
+	}
+	fmt.Fprintf(w, "LF:%d\n", len(numbers))
+	fmt.Fprintf(w, "LH:%d\n", covered)
+	fmt.Fprintln(w, "end_of_record")
+}
+
+func (p *Profile) sortedBranches() []*Branch {
+	branches := append([]*Branch{}, p.Branches...)
+	sort.SliceStable(branches, func(a, b int) bool { return branches[a].Line < branches[b].Line })
+	return branches
+}
+
+func (p *Profile) takenArms() int {
+	taken := 0
+	for _, b := range p.Branches {
+		for _, hits := range b.Hits {
+			if hits > 0 {
+				taken++
+			}
+		}
+	}
+	return taken
+}
+
+func percent(covered int, total int) string {
+	if total == 0 {
+		return "0/0"
+	}
+	return fmt.Sprintf("%d/%d (%.1f%%)", covered, total, 100*float64(covered)/float64(total))
+}
@@ -2,22 +2,81 @@ package Environment
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/AnshVM/golox/Error"
 	"github.com/AnshVM/golox/Tokens"
 )
 
+// Tasks started with spawn share the environments their closures capture,
+// so every access to Values goes through the lock
 type Environment struct {
 	Values    map[string]any
 	Enclosing *Environment
+	mu        sync.RWMutex
+	// names declared constant, which Assign and Declare refuse to change
+	constants map[string]bool
 }
 
 func (env *Environment) Define(name string, value any) {
+	env.mu.Lock()
+	defer env.mu.Unlock()
 	env.Values[name] = value
 }
 
+// Defines the name declared by a var, const or fun. A constant can't be
+// declared again, so a global constant of a library can't be replaced by
+// accident
+func (env *Environment) Declare(name *Tokens.Token, value any, constant bool) error {
+	env.mu.Lock()
+	defer env.mu.Unlock()
+	if env.constants[name.Lexeme] {
+		Error.ReportRuntimeError(name, fmt.Sprintf("Can't redeclare constant '%s'.", name.Lexeme))
+		return Error.ErrRuntimeError
+	}
+	env.Values[name.Lexeme] = value
+	if constant {
+		if env.constants == nil {
+			env.constants = map[string]bool{}
+		}
+		env.constants[name.Lexeme] = true
+	}
+	return nil
+}
+
+// looks the name up in this scope only
+func (env *Environment) lookup(name string) (any, bool) {
+	env.mu.RLock()
+	defer env.mu.RUnlock()
+	val, ok := env.Values[name]
+	return val, ok
+}
+
+// sets the name in this scope if it is defined here and isn't constant
+func (env *Environment) set(name string, value any) (defined bool, constant bool) {
+	env.mu.Lock()
+	defer env.mu.Unlock()
+	if _, ok := env.Values[name]; !ok {
+		return false, false
+	}
+	if env.constants[name] {
+		return true, true
+	}
+	env.Values[name] = value
+	return true, false
+}
+
+func assignError(name *Tokens.Token, constant bool) error {
+	if constant {
+		Error.ReportRuntimeError(name, fmt.Sprintf("Can't assign to constant '%s'.", name.Lexeme))
+	} else {
+		Error.ReportRuntimeError(name, fmt.Sprintf("Undefined variable %s", name.Lexeme))
+	}
+	return Error.ErrRuntimeError
+}
+
 func (env *Environment) Get(name *Tokens.Token) (any, error) {
-	if val, ok := env.Values[name.Lexeme]; ok {
+	if val, ok := env.lookup(name.Lexeme); ok {
 		return val, nil
 	} else if env.Enclosing != nil {
 		return env.Enclosing.Get(name)
@@ -28,29 +87,39 @@ func (env *Environment) Get(name *Tokens.Token) (any, error) {
 }
 
 func (env *Environment) Assign(name *Tokens.Token, value any) error {
-	if _, ok := env.Values[name.Lexeme]; ok {
-		env.Values[name.Lexeme] = value
+	if defined, constant := env.set(name.Lexeme, value); constant {
+		return assignError(name, true)
+	} else if defined {
+		return nil
 	} else if env.Enclosing != nil {
-		env.Enclosing.Assign(name, value)
+		return env.Enclosing.Assign(name, value)
 	} else {
-		Error.ReportRuntimeError(name, fmt.Sprintf("Undefined variable %s", name.Lexeme))
-		return Error.ErrRuntimeError
+		return assignError(name, false)
 	}
-	return nil
 }
 
+// The resolver found the variable distance scopes up, so it is looked up
+// in that scope only and never in the ones around it
 func (env *Environment) AssignAt(distance int, name *Tokens.Token, value any) error {
-	return env.ancestor(distance).Assign(name, value)
+	if defined, constant := env.ancestor(distance).set(name.Lexeme, value); !defined || constant {
+		return assignError(name, constant)
+	}
+	return nil
 }
 
 func (env *Environment) GetAt(distance int, name *Tokens.Token) (any, error) {
-	return env.ancestor(distance).Get(name)
+	value, ok := env.ancestor(distance).lookup(name.Lexeme)
+	if !ok {
+		Error.ReportRuntimeError(name, fmt.Sprintf("Undefined variable %s", name.Lexeme))
+		return nil, Error.ErrRuntimeError
+	}
+	return value, nil
 }
 
 func (env *Environment) ancestor(distance int) *Environment {
 	curr := env
 	for i := 0; i < distance; i++ {
-		curr = env.Enclosing
+		curr = curr.Enclosing
 	}
 	return curr
 }
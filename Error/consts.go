@@ -8,4 +8,9 @@ var (
 	ErrRuntimeError     = errors.New("RuntimeError")
 	ErrReturn           = errors.New("Return")
 	ErrStackOutOfBounds = errors.New("StackOutOfBounds")
+	ErrStackOverflow    = errors.New("StackOverflow")
+	ErrBudgetExhausted  = errors.New("BudgetExhausted")
+	ErrTimeout          = errors.New("Timeout")
+	ErrMemoryLimit      = errors.New("MemoryLimit")
+	ErrClosed           = errors.New("Closed")
 )
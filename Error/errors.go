@@ -2,20 +2,61 @@ package Error
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"sync"
 
 	"github.com/AnshVM/golox/Tokens"
 )
 
+type Severity int
+
+const (
+	WARNING Severity = iota
+	ERROR
+)
+
+func (s Severity) String() string {
+	if s == WARNING {
+		return "Warning"
+	}
+	return "Error"
+}
+
+// Diagnostics go to stderr so they don't mix with what the program prints
+var Output io.Writer = os.Stderr
+
 var HadError = false
 var HadRuntimeError = false
+var HadWarning = false
+
+// Set by -Werror, makes every warning stop the program like an error
+var WarningsAsErrors = false
 
 func PrintError(line uint, where string, message string) {
-	fmt.Println("[line " + fmt.Sprint(line+1) + "] Error " + where + ": " + message)
+	PrintDiagnostic(ERROR, line, where, message)
+}
+
+func PrintDiagnostic(severity Severity, line uint, where string, message string) {
+	fmt.Fprintln(Output, "[line "+fmt.Sprint(line+1)+"] "+severity.String()+" "+where+": "+message)
 }
 
 func Report(line uint, where string, message string) {
-	PrintError(line, where, message)
-	HadError = true
+	ReportWithSeverity(ERROR, line, where, message)
+}
+
+// Errors stop the program before it runs, warnings are only printed
+func ReportWithSeverity(severity Severity, line uint, where string, message string) {
+	PrintDiagnostic(severity, line, where, message)
+	if severity == ERROR || WarningsAsErrors {
+		HadError = true
+	} else {
+		HadWarning = true
+	}
+}
+
+func ReportWarning(token *Tokens.Token, message string) {
+	ReportWithSeverity(WARNING, token.Line, fmt.Sprintf("at '%s'", token.Lexeme), message)
 }
 
 func ReportParseError(token *Tokens.Token, message string) {
@@ -30,12 +71,20 @@ func ReportScanError(line uint, message string) {
 	Report(line, "", message)
 }
 
+// Spawned tasks report runtime errors concurrently
+var runtimeMu sync.Mutex
+
 func ReportRuntimeError(token *Tokens.Token, message string) {
+	runtimeMu.Lock()
+	defer runtimeMu.Unlock()
 	PrintError(token.Line, fmt.Sprintf("at '%s'", token.Lexeme), message)
 	HadRuntimeError = true
 }
 
-func ReportResolverError(message string) {
-	fmt.Println(message)
-	HadError = true
+// for runtime errors that are not tied to a single token, like exceeding a limit
+func ReportLimitError(message string) {
+	runtimeMu.Lock()
+	defer runtimeMu.Unlock()
+	fmt.Fprintln(Output, "Error: "+message)
+	HadRuntimeError = true
 }
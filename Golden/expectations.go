@@ -0,0 +1,55 @@
+package Golden
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// What a test program should do, read from comments in its source following
+// the convention of the Crafting Interpreters test suite:
+//
+//	print 1 + 2; // expect: 3
+//	print -"a";  // expect runtime error: Operand must be a number
+//	var a = ;    // Error at ';': Unexpected token
+//	// [line 7] Error at end: Expect '}' after block.
+type Expectations struct {
+	Output        []string
+	RuntimeError  string
+	CompileErrors []string
+}
+
+var (
+	expectOutput       = regexp.MustCompile(`// expect: ?(.*)`)
+	expectRuntimeError = regexp.MustCompile(`// expect runtime error: (.+)`)
+	expectCompileError = regexp.MustCompile(`// (\[line (\d+)\] )?(Error.*)`)
+)
+
+func ParseExpectations(source string) Expectations {
+	e := Expectations{Output: []string{}, CompileErrors: []string{}}
+	for index, line := range strings.Split(source, "\n") {
+		if match := expectOutput.FindStringSubmatch(line); match != nil {
+			e.Output = append(e.Output, match[1])
+		} else if match := expectRuntimeError.FindStringSubmatch(line); match != nil {
+			e.RuntimeError = match[1]
+		} else if match := expectCompileError.FindStringSubmatch(line); match != nil {
+			errorLine := match[2]
+			if errorLine == "" {
+				errorLine = fmt.Sprint(index + 1)
+			}
+			e.CompileErrors = append(e.CompileErrors, fmt.Sprintf("[line %s] %s", errorLine, match[3]))
+		}
+	}
+	return e
+}
+
+// The exit code golox uses for the expected outcome
+func (e Expectations) ExitCode() int {
+	switch {
+	case len(e.CompileErrors) > 0:
+		return 65
+	case e.RuntimeError != "":
+		return 70
+	}
+	return 0
+}
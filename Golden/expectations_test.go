@@ -0,0 +1,42 @@
+package Golden
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseExpectations(t *testing.T) {
+	tests := []struct {
+		source   string
+		expected Expectations
+		exitCode int
+	}{
+		{
+			"print 1; // expect: 1\nprint \"\"; // expect:\n",
+			Expectations{Output: []string{"1", ""}, CompileErrors: []string{}},
+			0,
+		},
+		{
+			"print 1; // expect: 1\nprint -\"a\"; // expect runtime error: Operand must be a number.\n",
+			Expectations{Output: []string{"1"}, RuntimeError: "Operand must be a number.", CompileErrors: []string{}},
+			70,
+		},
+		{
+			"var a = ; // Error at ';': Expect expression.\n{\n// [line 4] Error at end: Expect '}' after block.\n",
+			Expectations{Output: []string{}, CompileErrors: []string{
+				"[line 1] Error at ';': Expect expression.",
+				"[line 4] Error at end: Expect '}' after block.",
+			}},
+			65,
+		},
+	}
+	for _, test := range tests {
+		got := ParseExpectations(test.source)
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("ParseExpectations(%q) = %#v, want %#v", test.source, got, test.expected)
+		}
+		if got.ExitCode() != test.exitCode {
+			t.Errorf("ParseExpectations(%q).ExitCode() = %d, want %d", test.source, got.ExitCode(), test.exitCode)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package Golden
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Runs test programs with a golox binary and compares the outcome with the
+// expectations written in their comments
+type Runner struct {
+	Interpreter string        // path of the golox binary
+	Args        []string      // flags passed before the script, like -optimize
+	Timeout     time.Duration // per test, 0 for none
+}
+
+type Result struct {
+	Path     string
+	Failures []string
+}
+
+func (r Result) Passed() bool {
+	return len(r.Failures) == 0
+}
+
+// Runs every .lox file below dir in lexical order
+func (r *Runner) RunDir(dir string) ([]Result, error) {
+	paths := []string{}
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() && filepath.Ext(path) == ".lox" {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	results := []Result{}
+	for _, path := range paths {
+		results = append(results, r.RunFile(path))
+	}
+	return results, nil
+}
+
+func (r *Runner) RunFile(path string) Result {
+	result := Result{Path: path}
+	source, err := ioutil.ReadFile(path)
+	if err != nil {
+		result.Failures = append(result.Failures, err.Error())
+		return result
+	}
+	expected := ParseExpectations(string(source))
+
+	ctx := context.Background()
+	if r.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.Timeout)
+		defer cancel()
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, r.Interpreter, append(append([]string{}, r.Args...), path)...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+
+	exitCode := 0
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		exitCode = exitErr.ExitCode()
+	} else if err != nil {
+		result.Failures = append(result.Failures, err.Error())
+		return result
+	}
+	if ctx.Err() != nil {
+		result.Failures = append(result.Failures, fmt.Sprintf("timed out after %v", r.Timeout))
+		return result
+	}
+
+	errorLines := lines(stderr.String())
+	for _, compileError := range expected.CompileErrors {
+		if !contains(errorLines, compileError) {
+			result.Failures = append(result.Failures, fmt.Sprintf("missing compile error %q", compileError))
+		}
+	}
+	if expected.RuntimeError != "" && !strings.Contains(stderr.String(), expected.RuntimeError) {
+		result.Failures = append(result.Failures, fmt.Sprintf("missing runtime error %q", expected.RuntimeError))
+	}
+	if exitCode != expected.ExitCode() {
+		failure := fmt.Sprintf("expected exit code %d, got %d", expected.ExitCode(), exitCode)
+		if stderr.Len() > 0 {
+			failure += "\n" + indent(stderr.String())
+		}
+		result.Failures = append(result.Failures, failure)
+	}
+	if diff := diff(expected.Output, lines(stdout.String())); diff != "" {
+		result.Failures = append(result.Failures, "output differs:\n"+diff)
+	}
+	return result
+}
+
+// Line by line comparison, '-' marks expected lines and '+' actual ones
+func diff(expected []string, actual []string) string {
+	var b strings.Builder
+	for i := 0; i < len(expected) || i < len(actual); i++ {
+		switch {
+		case i >= len(actual):
+			fmt.Fprintf(&b, "  %d - %s\n", i+1, expected[i])
+		case i >= len(expected):
+			fmt.Fprintf(&b, "  %d + %s\n", i+1, actual[i])
+		case expected[i] != actual[i]:
+			fmt.Fprintf(&b, "  %d - %s\n  %d + %s\n", i+1, expected[i], i+1, actual[i])
+		}
+	}
+	return b.String()
+}
+
+func lines(output string) []string {
+	output = strings.TrimSuffix(output, "\n")
+	if output == "" {
+		return []string{}
+	}
+	return strings.Split(output, "\n")
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func indent(text string) string {
+	return "  " + strings.ReplaceAll(strings.TrimSuffix(text, "\n"), "\n", "\n  ")
+}
@@ -0,0 +1,218 @@
+package Interpreter
+
+import (
+	"github.com/AnshVM/golox/Ast"
+	"github.com/AnshVM/golox/Environment"
+	"github.com/AnshVM/golox/Error"
+)
+
+// Future is what calling an async function returns, it settles with what
+// the function returns. await waits for it, then(fn) calls fn with its value
+type Future struct {
+	settled bool
+	value   any
+	// run in the event loop once the future settles
+	callbacks []func(i *Interpreter, value any) error
+}
+
+// Settles f and queues its callbacks, the scheduler must be locked
+func (f *Future) settle(i *Interpreter, value any) {
+	f.settled = true
+	f.value = value
+	for _, callback := range f.callbacks {
+		callback := callback
+		i.loop.post(i.sched, func(i *Interpreter) error {
+			return callback(i, value)
+		})
+	}
+	f.callbacks = nil
+}
+
+// Runs callback in the event loop once f settles, the scheduler must be locked
+func (f *Future) onSettle(i *Interpreter, callback func(i *Interpreter, value any) error) {
+	if !f.settled {
+		f.callbacks = append(f.callbacks, callback)
+		return
+	}
+	value := f.value
+	i.loop.post(i.sched, func(i *Interpreter) error {
+		return callback(i, value)
+	})
+}
+
+// future() makes a future that settles when its resolve(value) is called,
+// sleep(ms) one that settles with nil ms milliseconds from now
+func futureNatives() map[string]*LoxCallable {
+	return map[string]*LoxCallable{
+		"future": native(0, func(i *Interpreter, _ []any) (any, error) {
+			if err := i.alloc(); err != nil {
+				return nil, err
+			}
+			return &Future{}, nil
+		}),
+		"sleep": native(1, func(i *Interpreter, args []any) (any, error) {
+			delay, err := i.durationArg("sleep", args[0])
+			if err != nil {
+				return nil, err
+			}
+			if err := i.alloc(); err != nil {
+				return nil, err
+			}
+			future := &Future{}
+			resolve := native(0, func(i *Interpreter, _ []any) (any, error) {
+				i.sched.mu.Lock()
+				defer i.sched.mu.Unlock()
+				future.settle(i, nil)
+				return nil, nil
+			})
+			i.sched.mu.Lock()
+			defer i.sched.mu.Unlock()
+			i.loop.setTimer(i.sched, &timer{due: delay, callback: resolve, callSite: i.callSite})
+			return future, nil
+		}),
+	}
+}
+
+// resolve(value) settles the future, then(fn) returns a future that settles
+// with what fn returns once fn was called with the value of this one.
+// done() tells whether it has settled
+func (f *Future) property(name string) (*LoxCallable, bool) {
+	switch name {
+	case "resolve":
+		return native(1, func(i *Interpreter, args []any) (any, error) {
+			i.sched.mu.Lock()
+			defer i.sched.mu.Unlock()
+			if f.settled {
+				return nil, i.nativeError("Future is already settled.")
+			}
+			f.settle(i, args[0])
+			return nil, nil
+		}), true
+	case "then":
+		return native(1, func(i *Interpreter, args []any) (any, error) {
+			function, ok := args[0].(*LoxCallable)
+			if !ok {
+				return nil, i.nativeError("then expects a function, got " + Stringify(args[0]) + ".")
+			}
+			if err := i.alloc(); err != nil {
+				return nil, err
+			}
+			next := &Future{}
+			callSite := i.callSite
+			i.sched.mu.Lock()
+			defer i.sched.mu.Unlock()
+			f.onSettle(i, func(i *Interpreter, value any) error {
+				result, err := i.callFunction(function, []any{value}, callSite)
+				if err != nil {
+					return err
+				}
+				i.sched.mu.Lock()
+				defer i.sched.mu.Unlock()
+				// a future returned by fn is waited for, like a promise
+				if chained, ok := result.(*Future); ok {
+					chained.onSettle(i, func(i *Interpreter, value any) error {
+						i.sched.mu.Lock()
+						defer i.sched.mu.Unlock()
+						next.settle(i, value)
+						return nil
+					})
+					return nil
+				}
+				next.settle(i, result)
+				return nil
+			})
+			return next, nil
+		}), true
+	case "done":
+		return native(0, func(i *Interpreter, _ []any) (any, error) {
+			i.sched.mu.Lock()
+			defer i.sched.mu.Unlock()
+			return f.settled, nil
+		}), true
+	}
+	return nil, false
+}
+
+// The body of an async function runs on a goroutine of its own, with an
+// interpreter of its own, so it can stop at an await. Only one side runs at
+// a time: whoever started or resumed the body waits until it stops at an
+// await or finishes
+type coroutine struct {
+	resume chan any   // the value of the awaited future
+	paused chan error // the body stopped at an await, or finished with the error
+}
+
+// Runs the body up to its first await and returns the future it settles
+func (i *Interpreter) startAsync(body []Ast.Stmt, env *Environment.Environment) (*Future, error) {
+	future := &Future{}
+	co := &coroutine{resume: make(chan any), paused: make(chan error)}
+	forked := i.fork(env)
+	forked.coroutine = co
+	go func() {
+		err := forked.executeBlock(body, env)
+		var result any
+		if err == Error.ErrReturn {
+			result, err = forked.ReturnValue, nil
+		}
+		if err == nil {
+			forked.sched.mu.Lock()
+			future.settle(forked, result)
+			forked.sched.mu.Unlock()
+		}
+		select {
+		case co.paused <- err:
+		case <-forked.sched.closed:
+		}
+	}()
+	return future, <-co.paused
+}
+
+func (i *Interpreter) EvalAwait(expr *Ast.AwaitExpr) (any, error) {
+	value, err := i.Eval(expr.Value)
+	if err != nil {
+		return nil, err
+	}
+	future, ok := value.(*Future)
+	if !ok {
+		// awaiting anything else gives it back, like awaiting a settled future
+		return value, nil
+	}
+	s := i.sched
+	s.mu.Lock()
+	if future.settled {
+		s.mu.Unlock()
+		return future.value, nil
+	}
+	if i.coroutine == nil {
+		s.mu.Unlock()
+		return i.awaitInLoop(expr, future)
+	}
+	co := i.coroutine
+	future.onSettle(i, func(i *Interpreter, value any) error {
+		co.resume <- value
+		return <-co.paused
+	})
+	s.mu.Unlock()
+	co.paused <- nil
+	select {
+	case value := <-co.resume:
+		return value, nil
+	case <-i.sched.closed:
+		return nil, Error.ErrClosed
+	}
+}
+
+// At the top level await runs the event loop until the future settles
+func (i *Interpreter) awaitInLoop(expr *Ast.AwaitExpr, future *Future) (any, error) {
+	err := i.runLoop(func() bool { return future.settled })
+	if err != nil {
+		return nil, err
+	}
+	i.sched.mu.Lock()
+	defer i.sched.mu.Unlock()
+	if !future.settled {
+		Error.ReportRuntimeError(expr.Keyword, "Awaited future never settles.")
+		return nil, Error.ErrRuntimeError
+	}
+	return future.value, nil
+}
@@ -1,6 +1,94 @@
 package Interpreter
 
+import (
+	"fmt"
+
+	"github.com/AnshVM/golox/Tokens"
+)
+
+// Variadic is the most arguments of a callable that takes any number of them
+const Variadic = ^uint(0)
+
 type LoxCallable struct {
-	Arity func() uint
+	// the least and the most arguments a call can pass
+	Arity func() (uint, uint)
 	Call  func(interpreter *Interpreter, arguments []any) (any, error)
+	// the names of the params of a function declared in Lox, nil for natives
+	Params []string
+}
+
+// Stands in for the params a call with named arguments leaves out, so they
+// get their default
+type missingArgument struct{}
+
+func isMissing(arg any) bool {
+	_, ok := arg.(missingArgument)
+	return ok
+}
+
+// Returns the error for a call passing count arguments, or "" when the
+// callable takes that many
+func (c *LoxCallable) arityError(count int) string {
+	least, most := c.Arity()
+	if uint(count) >= least && uint(count) <= most {
+		return ""
+	}
+	switch {
+	case least == most:
+		return fmt.Sprintf("Expected %d arguments, got %d", least, count)
+	case most == Variadic:
+		return fmt.Sprintf("Expected at least %d arguments, got %d", least, count)
+	default:
+		return fmt.Sprintf("Expected %d to %d arguments, got %d", least, most, count)
+	}
+}
+
+// Puts the named arguments of a call in the place of their param, after the
+// positional ones. Returns the error for a call that doesn't bind every
+// param without a default, or "" when it does
+func (c *LoxCallable) bindNamed(args []any, names []*Tokens.Token) ([]any, string) {
+	if c.Params == nil {
+		return nil, "Only functions declared in Lox take named arguments."
+	}
+	least, most := c.Arity()
+	fixed := len(c.Params)
+	if most == Variadic && fixed > 0 {
+		fixed--
+	}
+	bound := []any{}
+	for index, arg := range args {
+		if index >= len(names) || names[index] == nil {
+			bound = append(bound, arg)
+			continue
+		}
+		name := names[index].Lexeme
+		position := -1
+		for at, param := range c.Params[:fixed] {
+			if param == name {
+				position = at
+			}
+		}
+		if position == -1 {
+			if fixed < len(c.Params) && c.Params[fixed] == name {
+				return nil, fmt.Sprintf("Can't pass the rest parameter '%s' by name.", name)
+			}
+			return nil, fmt.Sprintf("Unknown parameter '%s'.", name)
+		}
+		for len(bound) <= position {
+			bound = append(bound, missingArgument{})
+		}
+		if !isMissing(bound[position]) {
+			return nil, fmt.Sprintf("Parameter '%s' is passed twice.", name)
+		}
+		bound[position] = arg
+	}
+	if uint(len(bound)) > most {
+		return nil, c.arityError(len(bound))
+	}
+	for index := 0; index < int(least); index++ {
+		if index >= len(bound) || isMissing(bound[index]) {
+			return nil, fmt.Sprintf("Missing argument for parameter '%s'.", c.Params[index])
+		}
+	}
+	return bound, ""
 }
@@ -0,0 +1,39 @@
+package Interpreter
+
+import (
+	"testing"
+
+	"github.com/AnshVM/golox/Tokens"
+)
+
+func TestBindNamed(t *testing.T) {
+	arity := func(least uint, most uint) func() (uint, uint) {
+		return func() (uint, uint) { return least, most }
+	}
+	name := func(lexeme string) *Tokens.Token {
+		return &Tokens.Token{Type: Tokens.IDENTIFIER, Lexeme: lexeme}
+	}
+	tests := []struct {
+		name     string
+		callable LoxCallable
+		names    []*Tokens.Token
+		err      string
+	}{
+		{"by name", LoxCallable{Arity: arity(2, 2), Params: []string{"a", "b"}}, []*Tokens.Token{nil, name("b")}, ""},
+		{"unknown", LoxCallable{Arity: arity(1, 1), Params: []string{"a"}}, []*Tokens.Token{name("c")}, "Unknown parameter 'c'."},
+		{"rest", LoxCallable{Arity: arity(0, Variadic), Params: []string{"rest"}}, []*Tokens.Token{name("rest")}, "Can't pass the rest parameter 'rest' by name."},
+		// only a malformed syntax tree gives a variadic function no params
+		{"variadic without params", LoxCallable{Arity: arity(0, Variadic), Params: []string{}}, []*Tokens.Token{name("a")}, "Unknown parameter 'a'."},
+		{"twice", LoxCallable{Arity: arity(1, 1), Params: []string{"a"}}, []*Tokens.Token{name("a"), name("a")}, "Parameter 'a' is passed twice."},
+	}
+	for _, test := range tests {
+		args := make([]any, len(test.names))
+		for index := range args {
+			args[index] = float32(index)
+		}
+		_, err := test.callable.bindNamed(args, test.names)
+		if err != test.err {
+			t.Errorf("%s: error %q, want %q", test.name, err, test.err)
+		}
+	}
+}
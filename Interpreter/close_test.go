@@ -0,0 +1,59 @@
+package Interpreter_test
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/AnshVM/golox/Environment"
+	"github.com/AnshVM/golox/Error"
+	"github.com/AnshVM/golox/Interpreter"
+	"github.com/AnshVM/golox/Parser"
+	"github.com/AnshVM/golox/Resolver"
+	"github.com/AnshVM/golox/Scanner"
+)
+
+// A generator nobody finishes and an async body awaiting a future that never
+// settles keep a goroutine each until the interpreter is closed
+func TestCloseReleasesPausedBodies(t *testing.T) {
+	source := `
+fun numbers() {
+  var n = 0;
+  while (true) {
+    yield n;
+    n = n + 1;
+  }
+}
+var gen = numbers();
+gen.next();
+
+async fun forever() {
+  await future();
+}
+forever();
+`
+	before := runtime.NumGoroutine()
+	scanner := Scanner.NewScanner(source)
+	stmts := Parser.NewParser(scanner.ScanTokens()).Parse()
+	interpreter := Interpreter.NewInterpreter(&Environment.Environment{Values: map[string]any{}})
+	Resolver.NewResolver(interpreter).Resolve(stmts)
+	if Error.HadError {
+		t.Fatal("the program doesn't resolve")
+	}
+	if err := interpreter.Interpret(stmts); err != nil {
+		t.Fatal(err)
+	}
+	if paused := runtime.NumGoroutine() - before; paused != 2 {
+		t.Fatalf("%d goroutines still running after the program, want 2", paused)
+	}
+
+	interpreter.Close()
+	interpreter.Close() // closing twice does nothing
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d goroutines still running after Close", runtime.NumGoroutine()-before)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,309 @@
+package Interpreter
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/AnshVM/golox/Ast"
+)
+
+// LoopClock tells the event loop the time, counted from when the clock was made
+type LoopClock interface {
+	Now() time.Duration
+	// Waits for d to pass, returns early once wake gets a value or ctx is done
+	Wait(ctx context.Context, d time.Duration, wake <-chan struct{})
+}
+
+type realClock struct {
+	start time.Time
+}
+
+func RealClock() LoopClock {
+	return realClock{start: time.Now()}
+}
+
+func (c realClock) Now() time.Duration {
+	return time.Since(c.start)
+}
+
+func (c realClock) Wait(ctx context.Context, d time.Duration, wake <-chan struct{}) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-wake:
+	case <-ctx.Done():
+	}
+}
+
+// VirtualClock never waits, it jumps straight to the next timer. Programs
+// using timers run instantly and fire them in the same order every time
+type VirtualClock struct {
+	now atomic.Int64
+}
+
+func NewVirtualClock() *VirtualClock {
+	return &VirtualClock{}
+}
+
+func (c *VirtualClock) Now() time.Duration {
+	return time.Duration(c.now.Load())
+}
+
+func (c *VirtualClock) Wait(_ context.Context, d time.Duration, _ <-chan struct{}) {
+	c.now.Add(int64(d))
+}
+
+// The event loop runs on the main interpreter once the program is done. It
+// makes the calls queued by timers, futures and the host one at a time, so
+// callbacks never run alongside each other. The scheduler lock guards it
+type eventLoop struct {
+	clock  LoopClock
+	queue  []func(i *Interpreter) error
+	timers []*timer // by when they are due, then by when they were set
+	active map[int]*timer
+	lastID int
+	// set while the loop waits for something to do
+	sleeper *waiter
+}
+
+type timer struct {
+	id       int
+	due      time.Duration // on the clock of the loop
+	interval time.Duration // 0 unless set by setInterval
+	callback *LoxCallable
+	callSite *Ast.Call // the setTimeout or setInterval call, for errors
+	cleared  bool
+}
+
+func newEventLoop() *eventLoop {
+	return &eventLoop{clock: RealClock(), active: map[int]*timer{}}
+}
+
+func (i *Interpreter) SetClock(clock LoopClock) {
+	i.loop.clock = clock
+}
+
+// Queues a call for the loop and wakes it, the scheduler must be locked
+func (loop *eventLoop) post(s *scheduler, call func(i *Interpreter) error) {
+	loop.queue = append(loop.queue, call)
+	loop.wake(s)
+}
+
+func (loop *eventLoop) wake(s *scheduler) {
+	if loop.sleeper != nil && !loop.sleeper.woken {
+		s.fire(loop.sleeper, 0)
+	}
+	loop.sleeper = nil
+}
+
+// Sets a timer and returns its id, the scheduler must be locked
+func (loop *eventLoop) setTimer(s *scheduler, t *timer) int {
+	loop.lastID++
+	t.id = loop.lastID
+	t.due += loop.clock.Now()
+	loop.active[t.id] = t
+	loop.schedule(t)
+	loop.wake(s)
+	return t.id
+}
+
+func (loop *eventLoop) schedule(t *timer) {
+	index := sort.Search(len(loop.timers), func(n int) bool {
+		return loop.timers[n].due > t.due
+	})
+	loop.timers = append(loop.timers, nil)
+	copy(loop.timers[index+1:], loop.timers[index:])
+	loop.timers[index] = t
+}
+
+func (loop *eventLoop) clear(id int) {
+	t, ok := loop.active[id]
+	if !ok {
+		return
+	}
+	t.cleared = true
+	delete(loop.active, id)
+	for index, scheduled := range loop.timers {
+		if scheduled == t {
+			loop.timers = append(loop.timers[:index], loop.timers[index+1:]...)
+			break
+		}
+	}
+}
+
+// Queues the call of the first timer, which is due. An interval is set
+// again for its next run
+func (loop *eventLoop) fire(s *scheduler) {
+	t := loop.timers[0]
+	loop.timers = loop.timers[1:]
+	if t.interval > 0 {
+		t.due += t.interval
+		loop.schedule(t)
+	}
+	loop.post(s, func(i *Interpreter) error {
+		// the timer may have been cleared while the call was queued
+		i.sched.mu.Lock()
+		cleared := t.cleared
+		if t.interval == 0 {
+			delete(loop.active, t.id)
+		}
+		i.sched.mu.Unlock()
+		if cleared {
+			return nil
+		}
+		_, err := i.callFunction(t.callback, nil, t.callSite)
+		return err
+	})
+}
+
+// Calls a function for the loop. callSite is the call that handed the
+// function over, like setTimeout, and nil for calls posted by the host
+func (i *Interpreter) callFunction(function *LoxCallable, args []any, callSite *Ast.Call) (any, error) {
+	i.callSite = callSite
+	if message := function.arityError(len(args)); message != "" {
+		return nil, i.nativeError(message)
+	}
+	return function.Call(i, args)
+}
+
+// Post queues a call of callback with args for the event loop. It is safe
+// to call from any goroutine, RunLoop makes the call
+func (i *Interpreter) Post(callback *LoxCallable, args ...any) {
+	i.sched.mu.Lock()
+	defer i.sched.mu.Unlock()
+	i.loop.post(i.sched, func(i *Interpreter) error {
+		_, err := i.callFunction(callback, args, nil)
+		return err
+	})
+}
+
+// RunLoop makes the queued calls and the calls of timers as they become
+// due, until none are left and no task that could add more is running.
+// Interpreting a program runs the loop at its end, hosts that Post calls
+// afterwards run it again
+func (i *Interpreter) RunLoop(ctx context.Context) error {
+	i.ctx = ctx
+	return i.runLoop(nil)
+}
+
+// Runs the loop until it is idle, or until settled tells it to stop
+func (i *Interpreter) runLoop(settled func() bool) error {
+	s := i.sched
+	loop := i.loop
+	for {
+		if i.ctx.Err() != nil {
+			return i.timedOut()
+		}
+		s.mu.Lock()
+		if settled != nil && settled() {
+			s.mu.Unlock()
+			return nil
+		}
+		if len(loop.queue) > 0 {
+			call := loop.queue[0]
+			loop.queue = loop.queue[1:]
+			s.mu.Unlock()
+			if err := call(i); err != nil {
+				return err
+			}
+			continue
+		}
+		if len(loop.timers) > 0 {
+			wait := loop.timers[0].due - loop.clock.Now()
+			if wait <= 0 {
+				loop.fire(s)
+				s.mu.Unlock()
+				continue
+			}
+			// the loop isn't blocked while a timer is pending, so waiting
+			// for it doesn't count towards a deadlock
+			w := newWaiter()
+			loop.sleeper = w
+			s.mu.Unlock()
+			loop.clock.Wait(i.ctx, wait, w.wake)
+			s.mu.Lock()
+			if loop.sleeper == w {
+				loop.sleeper = nil
+			}
+			s.mu.Unlock()
+			continue
+		}
+		if s.tasks > 1 {
+			// a running task may still set a timer, settle a future or finish
+			w := newWaiter()
+			loop.sleeper = w
+			woken := i.sleep(w)
+			if loop.sleeper == w {
+				loop.sleeper = nil
+			}
+			s.mu.Unlock()
+			if !woken {
+				return i.timedOut()
+			}
+			continue
+		}
+		s.mu.Unlock()
+		return nil
+	}
+}
+
+// setTimeout(fn, ms) calls fn once, ms milliseconds from now, and
+// setInterval(fn, ms) every ms milliseconds. Both return an id for
+// clearTimeout and clearInterval, which do the same
+func timerNatives() map[string]*LoxCallable {
+	clear := native(1, func(i *Interpreter, args []any) (any, error) {
+		if id, ok := args[0].(float32); ok {
+			i.sched.mu.Lock()
+			defer i.sched.mu.Unlock()
+			i.loop.clear(int(id))
+		}
+		return nil, nil
+	})
+	return map[string]*LoxCallable{
+		"setTimeout": native(2, func(i *Interpreter, args []any) (any, error) {
+			return i.setTimer("setTimeout", args, false)
+		}),
+		"setInterval": native(2, func(i *Interpreter, args []any) (any, error) {
+			return i.setTimer("setInterval", args, true)
+		}),
+		"clearTimeout":  clear,
+		"clearInterval": clear,
+	}
+}
+
+func (i *Interpreter) setTimer(name string, args []any, repeat bool) (any, error) {
+	function, ok := args[0].(*LoxCallable)
+	if !ok || function.arityError(0) != "" {
+		return nil, i.nativeError(fmt.Sprintf("%s expects a function without parameters, got %s.", name, Stringify(args[0])))
+	}
+	delay, err := i.durationArg(name, args[1])
+	if err != nil {
+		return nil, err
+	}
+	if repeat && delay == 0 {
+		return nil, i.nativeError("setInterval expects a positive interval.")
+	}
+	if err := i.alloc(); err != nil {
+		return nil, err
+	}
+	t := &timer{due: delay, callback: function, callSite: i.callSite}
+	if repeat {
+		t.interval = delay
+	}
+	i.sched.mu.Lock()
+	defer i.sched.mu.Unlock()
+	return float32(i.loop.setTimer(i.sched, t)), nil
+}
+
+// a number of milliseconds, at least 0
+func (i *Interpreter) durationArg(name string, arg any) (time.Duration, error) {
+	ms, ok := arg.(float32)
+	if !ok || ms < 0 {
+		return 0, i.nativeError(fmt.Sprintf("%s expects a number of milliseconds, got %s.", name, Stringify(arg)))
+	}
+	return time.Duration(float64(ms) * float64(time.Millisecond)), nil
+}
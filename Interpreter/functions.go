@@ -7,14 +7,67 @@ import (
 	"github.com/AnshVM/golox/Tokens"
 )
 
-func CreateFunctionCallable(body []Ast.Stmt, params []*Tokens.Token, closure *Environment.Environment) *LoxCallable {
-	Arity := func() uint {
-		return uint(len(params))
+// How the body of a function runs and what its last param takes, named so
+// call sites can't mix them up
+type FunctionKind struct {
+	IsGenerator bool
+	IsAsync     bool
+	IsVariadic  bool
+}
+
+// Calling a generator binds the arguments and returns a generator, its body
+// only starts running on the first next(). Calling an async function runs
+// its body up to the first await and returns a future. Defaults are
+// evaluated at every call that leaves their param out, in the environment
+// of the call so they can use the params before them
+func CreateFunctionCallable(body []Ast.Stmt, params []*Tokens.Token, defaults []Ast.Expr, kind FunctionKind, closure *Environment.Environment) *LoxCallable {
+	fixed := len(params)
+	if kind.IsVariadic {
+		fixed--
+	}
+	least, most := uint(0), uint(fixed)
+	for index := 0; index < fixed; index++ {
+		if index >= len(defaults) || defaults[index] == nil {
+			least = uint(index) + 1
+		}
+	}
+	if kind.IsVariadic {
+		most = Variadic
+	}
+	Arity := func() (uint, uint) {
+		return least, most
 	}
 	Call := func(interpreter *Interpreter, arguments []any) (any, error) {
+		if err := interpreter.alloc(); err != nil {
+			return nil, err
+		}
 		env := Environment.Environment{Values: map[string]any{}, Enclosing: closure}
 		for index, param := range params {
-			env.Define(param.Lexeme, arguments[index])
+			switch {
+			case kind.IsVariadic && index == len(params)-1:
+				if err := interpreter.alloc(); err != nil {
+					return nil, err
+				}
+				rest := &List{items: []any{}}
+				if index < len(arguments) {
+					rest.items = append(rest.items, arguments[index:]...)
+				}
+				env.Define(param.Lexeme, rest)
+			case index < len(arguments) && !isMissing(arguments[index]):
+				env.Define(param.Lexeme, arguments[index])
+			default:
+				value, err := interpreter.evalIn(defaults[index], &env)
+				if err != nil {
+					return nil, err
+				}
+				env.Define(param.Lexeme, value)
+			}
+		}
+		if kind.IsGenerator {
+			return newGenerator(body, &env), nil
+		}
+		if kind.IsAsync {
+			return interpreter.startAsync(body, &env)
 		}
 		err := interpreter.executeBlock(body, &env)
 		if err == Error.ErrReturn {
@@ -22,5 +75,9 @@ func CreateFunctionCallable(body []Ast.Stmt, params []*Tokens.Token, closure *En
 		}
 		return nil, err
 	}
-	return &LoxCallable{Arity: Arity, Call: Call}
+	names := make([]string, len(params))
+	for index, param := range params {
+		names[index] = param.Lexeme
+	}
+	return &LoxCallable{Arity: Arity, Call: Call, Params: names}
 }
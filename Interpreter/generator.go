@@ -0,0 +1,209 @@
+package Interpreter
+
+import (
+	"fmt"
+
+	"github.com/AnshVM/golox/Ast"
+	"github.com/AnshVM/golox/Environment"
+	"github.com/AnshVM/golox/Error"
+)
+
+// Generator is what calling a function that yields returns. Its body runs on
+// a goroutine of its own, with an interpreter of its own, so it can stop in
+// the middle of any statement at a yield. Only one side runs at a time: the
+// caller waits while the body runs, and the body waits at a yield until the
+// next call to next(). Tasks sharing a generator take turns, a call that
+// comes while another task runs the body waits for it to be done
+type Generator struct {
+	body    []Ast.Stmt
+	env     *Environment.Environment
+	resume  chan struct{}
+	results chan generatorResult
+
+	// guarded by the scheduler lock
+	running bool
+	turns   []*pending // calls waiting for the running one to be done
+
+	// only used by the call whose turn it is
+	started  bool
+	finished bool
+	// a value the body yielded that next() hasn't returned yet
+	buffered bool
+	value    any
+}
+
+type generatorResult struct {
+	value    any
+	finished bool
+	err      error
+}
+
+func newGenerator(body []Ast.Stmt, env *Environment.Environment) *Generator {
+	return &Generator{
+		body:    body,
+		env:     env,
+		resume:  make(chan struct{}),
+		results: make(chan generatorResult),
+	}
+}
+
+// Waits until no other call runs the generator. The body calling next() on
+// its own generator could never get a turn, so that is an error
+func (g *Generator) takeTurn(i *Interpreter) error {
+	if i.generator == g {
+		return i.nativeError("Generator is already running.")
+	}
+	s := i.sched
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !g.running {
+		g.running = true
+		return nil
+	}
+	// the call that is done hands its turn straight to this one
+	w := newWaiter()
+	g.turns = append(g.turns, &pending{w: w})
+	return i.await(w)
+}
+
+func (g *Generator) endTurn(i *Interpreter) {
+	s := i.sched
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if next := nextWaiter(&g.turns); next != nil {
+		s.fire(next.w, 0)
+		return
+	}
+	g.running = false
+}
+
+// Runs the body up to its next yield, or to its end. The caller has the turn
+func (g *Generator) advance(i *Interpreter) error {
+	if g.finished || g.buffered {
+		return nil
+	}
+	if !g.started {
+		g.started = true
+		forked := i.fork(g.env)
+		forked.generator = g
+		go g.run(forked)
+	} else {
+		g.resume <- struct{}{}
+	}
+	result := <-g.results
+	if result.err != nil || result.finished {
+		g.finished = true
+		return result.err
+	}
+	g.buffered = true
+	g.value = result.value
+	return nil
+}
+
+func (g *Generator) run(i *Interpreter) {
+	err := i.executeBlock(g.body, g.env)
+	if err == Error.ErrReturn {
+		err = nil
+	}
+	select {
+	case g.results <- generatorResult{finished: true, err: err}:
+	case <-i.sched.closed:
+	}
+}
+
+// next() returns the next value the generator yields, or nil once it has
+// finished. done() tells whether it has finished, running the body up to
+// its next yield to find out
+func (g *Generator) property(name string) (*LoxCallable, bool) {
+	switch name {
+	case "next":
+		return native(0, func(i *Interpreter, _ []any) (any, error) {
+			if err := g.takeTurn(i); err != nil {
+				return nil, err
+			}
+			defer g.endTurn(i)
+			if err := g.advance(i); err != nil {
+				return nil, err
+			}
+			value := g.value
+			g.buffered = false
+			g.value = nil
+			return value, nil
+		}), true
+	case "done":
+		return native(0, func(i *Interpreter, _ []any) (any, error) {
+			if err := g.takeTurn(i); err != nil {
+				return nil, err
+			}
+			defer g.endTurn(i)
+			if err := g.advance(i); err != nil {
+				return nil, err
+			}
+			return g.finished, nil
+		}), true
+	}
+	return nil, false
+}
+
+// An interpreter for the body of a generator, an async function or a
+// spawned task. It shares the program, its limits and its scheduler with i,
+// and has a call stack of its own. The resolved locals are only read once
+// the program runs, so they are shared too
+func (i *Interpreter) fork(env *Environment.Environment) *Interpreter {
+	forked := *i
+	forked.Env = env
+	forked.ReturnValue = nil
+	forked.callSite = nil
+	forked.generator = nil
+	forked.coroutine = nil
+	forked.depth = 0
+	return &forked
+}
+
+func (i *Interpreter) ExecYieldStmt(stmt *Ast.YieldStmt) error {
+	if i.generator == nil {
+		Error.ReportRuntimeError(stmt.Keyword, "Can't yield outside of a generator.")
+		return Error.ErrRuntimeError
+	}
+	var value any
+	if stmt.Value != nil {
+		var err error
+		value, err = i.Eval(stmt.Value)
+		if err != nil {
+			return err
+		}
+	}
+	i.generator.results <- generatorResult{value: value}
+	select {
+	case <-i.generator.resume:
+		return nil
+	case <-i.sched.closed:
+		return Error.ErrClosed
+	}
+}
+
+func (i *Interpreter) EvalGet(expr *Ast.GetExpr) (any, error) {
+	object, err := i.Eval(expr.Object)
+	if err != nil {
+		return nil, err
+	}
+	var property *LoxCallable
+	var ok bool
+	var kind string
+	switch o := object.(type) {
+	case *Generator:
+		property, ok = o.property(expr.Name.Lexeme)
+		kind = "Generators"
+	case *Future:
+		property, ok = o.property(expr.Name.Lexeme)
+		kind = "Futures"
+	default:
+		Error.ReportRuntimeError(expr.Name, "Only generators and futures have properties.")
+		return nil, Error.ErrRuntimeError
+	}
+	if !ok {
+		Error.ReportRuntimeError(expr.Name, fmt.Sprintf("%s have no property '%s'.", kind, expr.Name.Lexeme))
+		return nil, Error.ErrRuntimeError
+	}
+	return property, nil
+}
@@ -1,13 +1,48 @@
 package Interpreter
 
-import "time"
+import (
+	"time"
+
+	"github.com/AnshVM/golox/Ast"
+	"github.com/AnshVM/golox/Error"
+)
 
 func Clock() *LoxCallable {
 	Call := func(_ *Interpreter, _ []any) (any, error) {
 		return time.Now().Second(), nil
 	}
-	Arity := func() uint {
-		return 0
+	Arity := func() (uint, uint) {
+		return 0, 0
+	}
+	return &LoxCallable{Call: Call, Arity: Arity}
+}
+
+// Reports a runtime error at the call of a native function. Functions the
+// host posts to the event loop are called from nowhere in the program
+func (i *Interpreter) nativeError(message string) error {
+	if i.callSite == nil {
+		Error.ReportLimitError(message)
+		return Error.ErrRuntimeError
+	}
+	at, _ := Ast.Span(i.callSite)
+	Error.ReportRuntimeError(at, message)
+	return Error.ErrRuntimeError
+}
+
+// assert(cond, msg) is a runtime error at the call when cond is falsy, msg
+// can be left out
+func Assert() *LoxCallable {
+	Call := func(interpreter *Interpreter, arguments []any) (any, error) {
+		if isTruthy(arguments[0]) {
+			return nil, nil
+		}
+		if len(arguments) < 2 {
+			return nil, interpreter.nativeError("Assertion failed.")
+		}
+		return nil, interpreter.nativeError("Assertion failed: " + Stringify(arguments[1]))
+	}
+	Arity := func() (uint, uint) {
+		return 1, 2
 	}
 	return &LoxCallable{Call: Call, Arity: Arity}
 }
@@ -1,7 +1,12 @@
 package Interpreter
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
 
 	"github.com/AnshVM/golox/Ast"
 	"github.com/AnshVM/golox/Environment"
@@ -12,31 +17,95 @@ import (
 
 type Interpreter struct {
 	Env         *Environment.Environment
-	ReturnValue any //ugly hack to catch the return value in the Call, evaluated in the ExecReturnStmt func
+	ReturnValue any  //ugly hack to catch the return value in the Call, evaluated in the ExecReturnStmt func
+	Echo        bool // print the value of top level expression statements, used by the REPL
+	Out         io.Writer
+	Tracer      Tracer     // told what runs, nil when nobody is listening
+	callSite    *Ast.Call  // the call being made, for natives that report errors
+	generator   *Generator // the generator whose body this interpreter runs, if any
+	coroutine   *coroutine // the async function whose body this interpreter runs, if any
+	depth       int        // of nested calls, every task has a call stack of its own
 	locals      map[Ast.Expr]int
 	globals     *Environment.Environment
+	limits      Limits
+	usage       *usage
+	sched       *scheduler
+	loop        *eventLoop
+	ctx         context.Context
 }
 
 func NewInterpreter(env *Environment.Environment) *Interpreter {
 	env.Define("clock", Clock())
-	return &Interpreter{globals: env, Env: env, locals: map[Ast.Expr]int{}}
+	env.Define("assert", Assert())
+	for name, native := range stringNatives() {
+		env.Define(name, native)
+	}
+	for name, native := range listNatives() {
+		env.Define(name, native)
+	}
+	for name, native := range taskNatives() {
+		env.Define(name, native)
+	}
+	for name, native := range timerNatives() {
+		env.Define(name, native)
+	}
+	for name, native := range futureNatives() {
+		env.Define(name, native)
+	}
+	return &Interpreter{
+		globals: env,
+		Env:     env,
+		Out:     os.Stdout,
+		locals:  map[Ast.Expr]int{},
+		limits:  DefaultLimits(),
+		usage:   &usage{},
+		sched:   newScheduler(),
+		loop:    newEventLoop(),
+		ctx:     context.Background(),
+	}
+}
+
+func (i *Interpreter) Globals() *Environment.Environment {
+	return i.globals
 }
 
 func (i *Interpreter) Resolve(expr Ast.Expr, depth int) {
 	i.locals[expr] = depth
 }
 
+// Reports the depth recorded by the resolver, false for globals
+func (i *Interpreter) Depth(expr Ast.Expr) (int, bool) {
+	depth, ok := i.locals[expr]
+	return depth, ok
+}
+
 func (i *Interpreter) Interpret(stmts []Parser.Stmt) error {
+	return i.InterpretContext(context.Background(), stmts)
+}
+
+// Stops with a runtime error once ctx is cancelled. Once the statements
+// have run, runs the event loop until it is idle and waits for the tasks
+// the program spawned
+func (i *Interpreter) InterpretContext(ctx context.Context, stmts []Parser.Stmt) error {
+	i.ctx = ctx
+	i.usage = &usage{}
+	defer i.waitForTasks()
 	for _, stmt := range stmts {
 		err := i.Exec(stmt)
 		if err != nil {
 			return err
 		}
 	}
-	return nil
+	return i.runLoop(nil)
 }
 
 func (i *Interpreter) Exec(stmt Parser.Stmt) error {
+	if err := i.step(); err != nil {
+		return err
+	}
+	if i.Tracer != nil {
+		i.Tracer.Statement(stmt)
+	}
 	switch s := stmt.(type) {
 	case *Ast.ExpressionStmt:
 		return i.ExecExpressionStmt(s)
@@ -54,34 +123,67 @@ func (i *Interpreter) Exec(stmt Parser.Stmt) error {
 		return i.ExecNamedFuncStmt(s)
 	case *Ast.Return:
 		return i.ExecReturnStmt(s)
+	case *Ast.TestStmt:
+		// tests only run through RunTest
+		return nil
+	case *Ast.YieldStmt:
+		return i.ExecYieldStmt(s)
+	case *Ast.SelectStmt:
+		return i.ExecSelectStmt(s)
+	case *Ast.MatchStmt:
+		return i.ExecMatchStmt(s)
 	}
 	return nil
 }
 
+// Runs the body of a test in a scope of its own, after the rest of the
+// program has been interpreted
+func (i *Interpreter) RunTest(ctx context.Context, test *Ast.TestStmt) error {
+	i.ctx = ctx
+	i.usage = &usage{}
+	defer i.waitForTasks()
+	err := i.executeBlock(test.Body, &Environment.Environment{Enclosing: i.globals, Values: map[string]any{}})
+	if err != nil {
+		return err
+	}
+	return i.runLoop(nil)
+}
+
 func (i *Interpreter) ExecReturnStmt(stmt *Ast.Return) error {
 	var returnVal any = nil
-	var err error
 	if stmt.Value != nil {
+		var err error
 		returnVal, err = i.Eval(stmt.Value)
-		if err == nil {
-			err = Error.ErrReturn
+		if err != nil {
+			return err
 		}
 	}
 	i.ReturnValue = returnVal
-	return err
+	return Error.ErrReturn
 }
 
 func (i *Interpreter) ExecNamedFuncStmt(stmt *Ast.NamedFunction) error {
-	callable := CreateFunctionCallable(stmt.Body, stmt.Params, i.Env)
-	i.Env.Define(stmt.Name.Lexeme, callable)
-	return nil
+	if err := i.alloc(); err != nil {
+		return err
+	}
+	callable := CreateFunctionCallable(stmt.Body, stmt.Params, stmt.Defaults, FunctionKind{
+		IsGenerator: stmt.IsGenerator,
+		IsAsync:     stmt.IsAsync,
+		IsVariadic:  stmt.IsVariadic,
+	}, i.Env)
+	return i.Env.Declare(stmt.Name, callable, false)
 }
 
 func (i *Interpreter) ExecWhileStmt(stmt *Ast.WhileStmt) error {
 	for {
-		condition, err := i.Eval(stmt.Condition)
-		if err != nil {
-			return err
+		// the optimizer drops conditions that are always true
+		var condition any = true
+		if stmt.Condition != nil {
+			var err error
+			condition, err = i.Eval(stmt.Condition)
+			if err != nil {
+				return err
+			}
 		}
 		if isTruthy(condition) {
 			err := i.Exec(stmt.Body)
@@ -101,39 +203,48 @@ func (i *Interpreter) ExecIfStmt(stmt *Ast.IfStmt) error {
 		return err
 	}
 	if isTruthy(condition) {
+		i.branch(stmt, ThenArm)
 		return i.Exec(stmt.ThenBranch)
-	} else if stmt.ElseBranch != nil {
+	}
+	i.branch(stmt, ElseArm)
+	if stmt.ElseBranch != nil {
 		return i.Exec(stmt.ElseBranch)
 	}
 	return nil
 }
 
 func (i *Interpreter) ExecExpressionStmt(stmt *Ast.ExpressionStmt) error {
-	_, err := i.Eval(stmt.Expression)
+	value, err := i.Eval(stmt.Expression)
+	if err == nil && i.Echo && i.Env == i.globals && value != nil {
+		i.println(Stringify(value))
+	}
 	return err
 }
 
 func (i *Interpreter) ExecPrintStmt(stmt *Ast.PrintStmt) error {
 	result, err := i.Eval(stmt.Expression)
 	if err == nil {
-		fmt.Printf("%v\n", result)
+		i.println(Stringify(result))
 	}
 	return err
 }
 
 func (i *Interpreter) ExecVarStmt(stmt *Ast.VarStmt) error {
+	var value any
 	if stmt.Initializer != nil {
-		value, err := i.Eval(stmt.Initializer)
-		if err == nil {
-			i.Env.Define(stmt.Name.Lexeme, value)
+		var err error
+		value, err = i.Eval(stmt.Initializer)
+		if err != nil {
+			return err
 		}
-		return err
 	}
-	i.Env.Define(stmt.Name.Lexeme, nil)
-	return nil
+	return i.Env.Declare(stmt.Name, value, stmt.IsConst)
 }
 
 func (i *Interpreter) ExecBlockStmt(stmt *Ast.BlockStmt) error {
+	if err := i.alloc(); err != nil {
+		return err
+	}
 	err := i.executeBlock(stmt.Statements, &Environment.Environment{Enclosing: i.Env, Values: map[string]any{}})
 	return err
 }
@@ -170,46 +281,99 @@ func (i *Interpreter) Eval(expr Parser.Expr) (any, error) {
 		return i.EvalVariable(e)
 	case *Ast.AssignExpr:
 		return i.EvalAssign(e)
+	case *Ast.CompoundAssignExpr:
+		return i.EvalCompoundAssign(e)
+	case *Ast.IncrementExpr:
+		return i.EvalIncrement(e)
 	case *Ast.LogicalExpr:
 		return i.EvalLogical(e)
 	case *Ast.AnonymousFuncion:
 		return i.EvalAnonymousFunction(e)
 	case *Ast.Call:
 		return i.EvalCall(e)
+	case *Ast.InterpolationExpr:
+		return i.EvalInterpolation(e)
+	case *Ast.GetExpr:
+		return i.EvalGet(e)
+	case *Ast.SpawnExpr:
+		return i.EvalSpawn(e)
+	case *Ast.AwaitExpr:
+		return i.EvalAwait(e)
+	case *Ast.MatchExpr:
+		return i.EvalMatch(e)
 	}
 	return nil, Error.ErrRuntimeError
 }
 
 func (i *Interpreter) EvalAnonymousFunction(expr *Ast.AnonymousFuncion) (any, error) {
-	callable := CreateFunctionCallable(expr.Body, expr.Params, i.Env)
+	if err := i.alloc(); err != nil {
+		return nil, err
+	}
+	callable := CreateFunctionCallable(expr.Body, expr.Params, expr.Defaults, FunctionKind{
+		IsGenerator: expr.IsGenerator,
+		IsAsync:     expr.IsAsync,
+		IsVariadic:  expr.IsVariadic,
+	}, i.Env)
 	return callable, nil
 }
 
 func (i *Interpreter) EvalCall(expr *Ast.Call) (any, error) {
+	function, evaluatedArgs, err := i.prepareCall(expr)
+	if err != nil {
+		return nil, err
+	}
+	if err := i.enterCall(expr.Paren); err != nil {
+		return nil, err
+	}
+	defer i.exitCall()
+	i.callSite = expr
+	return function.Call(i, evaluatedArgs)
+}
 
+// Evaluates the callee and the arguments, and checks they fit together
+func (i *Interpreter) prepareCall(expr *Ast.Call) (*LoxCallable, []any, error) {
 	callee, err := i.Eval(expr.Callee)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	evaluatedArgs := []any{}
 	for _, argExpr := range expr.Arguments {
 		evalArg, err := i.Eval(argExpr)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		evaluatedArgs = append(evaluatedArgs, evalArg)
 	}
 	function, ok := callee.(*LoxCallable)
 	if !ok {
 		Error.ReportRuntimeError(expr.Paren, "Expression is not callable.")
-		return nil, Error.ErrRuntimeError
+		return nil, nil, Error.ErrRuntimeError
 	}
-	if len(evaluatedArgs) != int(function.Arity()) {
-		Error.ReportRuntimeError(expr.Paren, fmt.Sprintf("Expected %d arguments, got %d", function.Arity(), len(evaluatedArgs)))
-		return nil, Error.ErrRuntimeError
+	message := function.arityError(len(evaluatedArgs))
+	if expr.Names != nil {
+		evaluatedArgs, message = function.bindNamed(evaluatedArgs, expr.Names)
 	}
-	return function.Call(i, evaluatedArgs)
+	if message != "" {
+		Error.ReportRuntimeError(expr.Paren, message)
+		return nil, nil, Error.ErrRuntimeError
+	}
+	return function, evaluatedArgs, nil
+}
+
+func (i *Interpreter) EvalInterpolation(expr *Ast.InterpolationExpr) (any, error) {
+	var builder strings.Builder
+	for _, part := range expr.Parts {
+		value, err := i.Eval(part)
+		if err != nil {
+			return nil, err
+		}
+		builder.WriteString(Stringify(value))
+	}
+	if err := i.alloc(); err != nil {
+		return nil, err
+	}
+	return builder.String(), nil
 }
 
 func (i *Interpreter) EvalLogical(expr *Ast.LogicalExpr) (any, error) {
@@ -220,13 +384,16 @@ func (i *Interpreter) EvalLogical(expr *Ast.LogicalExpr) (any, error) {
 
 	if expr.Operator.Type == Tokens.OR {
 		if isTruthy(left) {
+			i.branch(expr, ShortCircuitArm)
 			return left, nil
 		}
 	} else {
 		if !isTruthy(left) {
+			i.branch(expr, ShortCircuitArm)
 			return left, nil
 		}
 	}
+	i.branch(expr, RightArm)
 	return i.Eval(expr.Right)
 
 }
@@ -237,13 +404,54 @@ func (i *Interpreter) EvalAssign(expr *Ast.AssignExpr) (any, error) {
 		return nil, err
 	}
 
+	return value, i.assignVariable(expr.Name, expr, value)
+}
+
+func (i *Interpreter) assignVariable(name *Tokens.Token, expr Ast.Expr, value any) error {
 	if distance, ok := i.locals[expr]; ok {
-		return value, i.Env.AssignAt(distance, expr.Name, value)
+		return i.Env.AssignAt(distance, name, value)
 	} else {
-		return value, i.globals.Assign(expr.Name, value)
+		return i.globals.Assign(name, value)
 	}
 }
 
+// The variable is read before the value is evaluated, like the left operand
+// of a binary expression, and written once
+func (i *Interpreter) EvalCompoundAssign(expr *Ast.CompoundAssignExpr) (any, error) {
+	current, err := i.lookupVariable(expr.Name, expr)
+	if err != nil {
+		return nil, err
+	}
+	value, err := i.Eval(expr.Value)
+	if err != nil {
+		return nil, err
+	}
+	result, err := i.binaryOp(expr.Operator, Tokens.CompoundOperators[expr.Operator.Type], current, value)
+	if err != nil {
+		return nil, err
+	}
+	return result, i.assignVariable(expr.Name, expr, result)
+}
+
+func (i *Interpreter) EvalIncrement(expr *Ast.IncrementExpr) (any, error) {
+	current, err := i.lookupVariable(expr.Name, expr)
+	if err != nil {
+		return nil, err
+	}
+	number, err := checkNumberOperand(expr.Operator, current)
+	if err != nil {
+		return nil, err
+	}
+	result, _ := i.binaryOp(expr.Operator, Tokens.CompoundOperators[expr.Operator.Type], number, float32(1))
+	if err := i.assignVariable(expr.Name, expr, result); err != nil {
+		return nil, err
+	}
+	if expr.IsPrefix {
+		return result, nil
+	}
+	return number, nil
+}
+
 func (i *Interpreter) EvalLiteral(expr *Ast.LiteralExpr) any {
 	return expr.Value
 }
@@ -291,51 +499,43 @@ func (i *Interpreter) lookupVariable(name *Tokens.Token, expr Ast.Expr) (any, er
 func (i *Interpreter) EvalConditional(conditional *Ast.ConditionalExpr) (any, error) {
 	cond, err := i.Eval(conditional.Condition)
 	if err != nil {
-		return nil, Error.ErrRuntimeError
+		return nil, err
 	}
-	if cond == true {
+	if isTruthy(cond) {
+		i.branch(conditional, ThenArm)
 		return i.Eval(conditional.Then)
 	} else {
+		i.branch(conditional, ElseArm)
 		return i.Eval(conditional.Else)
 	}
 }
 
 func (i *Interpreter) EvalBinary(binary *Ast.BinaryExpr) (any, error) {
-	switch binary.Operator.Type {
-	case Tokens.MINUS:
-		left, right, err := i.EvalBinaryOperandsNumber(binary)
-		if err != nil {
-			return nil, err
-		}
-		return (left - right), nil
-
-	case Tokens.SLASH:
-		left, right, err := i.EvalBinaryOperandsNumber(binary)
-		if err != nil {
-			return nil, err
-		}
-		if right == 0 {
-			Error.ReportRuntimeError(binary.Operator, "Cannot divide by zero")
-			return nil, Error.ErrRuntimeError
-		}
-		return (left / right), nil
+	left, right, err := i.EvalBinaryOperandsAny(binary)
+	if err != nil {
+		return nil, err
+	}
+	return i.binaryOp(binary.Operator, binary.Operator.Type, left, right)
+}
 
-	case Tokens.STAR:
-		left, right, err := i.EvalBinaryOperandsNumber(binary)
-		if err != nil {
-			return nil, err
-		}
-		return (left * right), nil
+// Applies the binary operator opType to operands that are already evaluated,
+// errors are reported at operator. Compound assignments pass their own
+// operator token with the type of the operator they apply
+func (i *Interpreter) binaryOp(operator *Tokens.Token, opType string, left any, right any) (any, error) {
+	switch opType {
+	case Tokens.EQUAL_EQUAL:
+		return isEqual(left, right), nil
+	case Tokens.BANG_EQUAL:
+		return !isEqual(left, right), nil
 
 	case Tokens.PLUS:
-		left, right, err := i.EvalBinaryOperandsAny(binary)
-		if err != nil {
-			return nil, err
-		}
 		if isFloat32(right) && isFloat32(left) {
 			return (left.(float32) + right.(float32)), nil
 		}
 		if isString(right) && isString(left) {
+			if err := i.alloc(); err != nil {
+				return nil, err
+			}
 			return (left.(string) + right.(string)), nil
 		}
 		// if isString(right) && isFloat32(left) {
@@ -346,86 +546,84 @@ func (i *Interpreter) EvalBinary(binary *Ast.BinaryExpr) (any, error) {
 		// 	val, _ := right.(float32)
 		// 	return (left.(string) + strconv.FormatFloat(float64(val), 'f', 0, 32)), nil
 		// }
-		Error.ReportRuntimeError(binary.Operator, "Operands must strings or numbers")
+		Error.ReportRuntimeError(operator, "Operands must strings or numbers")
 		return nil, Error.ErrRuntimeError
+	}
 
-	case Tokens.GREATER:
-		left, right, err := i.EvalBinaryOperandsNumber(binary)
-		if err != nil {
-			return nil, err
+	l, err := checkNumberOperand(operator, left)
+	if err != nil {
+		return nil, err
+	}
+	r, err := checkNumberOperand(operator, right)
+	if err != nil {
+		return nil, err
+	}
+	switch opType {
+	case Tokens.MINUS:
+		return (l - r), nil
+	case Tokens.SLASH, Tokens.PERCENT:
+		if r == 0 {
+			Error.ReportRuntimeError(operator, "Cannot divide by zero")
+			return nil, Error.ErrRuntimeError
 		}
-		return (left > right), nil
-
-	case Tokens.GREATER_EQUAL:
-		left, right, err := i.EvalBinaryOperandsNumber(binary)
-		if err != nil {
-			return nil, err
+		if opType == Tokens.PERCENT {
+			return float32(math.Mod(float64(l), float64(r))), nil
 		}
-		return (left >= right), nil
-
+		return (l / r), nil
+	case Tokens.STAR:
+		return (l * r), nil
+	case Tokens.GREATER:
+		return (l > r), nil
+	case Tokens.GREATER_EQUAL:
+		return (l >= r), nil
 	case Tokens.LESS:
-		left, right, err := i.EvalBinaryOperandsNumber(binary)
-		if err != nil {
-			return nil, err
-		}
-		return (left < right), nil
-
+		return (l < r), nil
 	case Tokens.LESS_EQAUL:
-		left, right, err := i.EvalBinaryOperandsNumber(binary)
-		if err != nil {
-			return nil, err
-		}
-		return (left <= right), nil
-
-	case Tokens.EQUAL_EQUAL:
-		left, right, err := i.EvalBinaryOperandsAny(binary)
-		if err != nil {
-			return nil, err
-		}
-		return isEqual(left, right), nil
-
-	case Tokens.BANG_EQUAL:
-		left, right, err := i.EvalBinaryOperandsAny(binary)
-		if err != nil {
-			return nil, err
-		}
-		return isEqual(left, right), nil
+		return (l <= r), nil
 	}
 
 	// unreachable
 	return nil, nil
 }
 
+// Operands are evaluated left to right, both before either is checked
 func (i *Interpreter) EvalBinaryOperandsAny(binary *Ast.BinaryExpr) (any, any, error) {
-	right, err := i.Eval(binary.Right)
+	left, err := i.Eval(binary.Left)
 	if err != nil {
 		return nil, nil, err
 	}
-	left, err := i.Eval(binary.Left)
+	right, err := i.Eval(binary.Right)
 	if err != nil {
 		return nil, nil, err
 	}
 	return left, right, nil
 }
 
-func (i *Interpreter) EvalBinaryOperandsNumber(binary *Ast.BinaryExpr) (float32, float32, error) {
-	evalRight, err := i.Eval(binary.Right)
-	if err != nil {
-		return 0, 0, err
-	}
-	evalLeft, err := i.Eval(binary.Left)
-	if err != nil {
-		return 0, 0, err
-	}
-	right, err := checkNumberOperand(binary.Operator, evalRight)
-	if err != nil {
-		return 0, 0, err
+// Prints a line of output, whole even when several tasks print at once
+func (i *Interpreter) println(line string) {
+	i.sched.out.Lock()
+	defer i.sched.out.Unlock()
+	fmt.Fprintln(i.Out, line)
+}
+
+// Formats a value the way `print` shows it
+func Stringify(value any) string {
+	if _, ok := value.(*LoxCallable); ok {
+		return "<fn>"
 	}
-	left, err := checkNumberOperand(binary.Operator, evalLeft)
-	if err != nil {
-		return 0, 0, err
+	switch v := value.(type) {
+	case *Generator:
+		return "<generator>"
+	case *Channel:
+		return "<chan>"
+	case *Task:
+		return "<task>"
+	case *Future:
+		return "<future>"
+	case *List:
+		return v.String()
 	}
-	return left, right, nil
+	return fmt.Sprintf("%v", value)
 }
 
 func checkNumberOperand(operator *Tokens.Token, right any) (float32, error) {
@@ -437,21 +635,12 @@ func checkNumberOperand(operator *Tokens.Token, right any) (float32, error) {
 	}
 }
 
-// Handles the special case that one operand is boolean, and other is not
+// Values of different types are never equal, there is no coercion.
+// Functions are only equal to themselves
 func isEqual(a any, b any) bool {
-	if isBool(a) && !isBool(b) {
-		b = isTruthy(b)
-	}
-	if isBool(b) && !isBool(a) {
-		a = isTruthy(a)
-	}
 	return a == b
 }
 
-func isBool(x any) bool {
-	return x == true || x == false
-}
-
 func isFloat32(val any) bool {
 	_, ok := val.(float32)
 	return ok
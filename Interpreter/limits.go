@@ -0,0 +1,76 @@
+package Interpreter
+
+import (
+	"sync/atomic"
+
+	"github.com/AnshVM/golox/Error"
+	"github.com/AnshVM/golox/Tokens"
+)
+
+// Deep enough for ordinary recursion, shallow enough to stay clear of the go stack limit
+const DefaultMaxDepth = 2000
+
+// Limits bounds the resources a program may use, a zero field means unlimited
+type Limits struct {
+	MaxDepth  int // nested function calls
+	MaxSteps  int // executed statements
+	MaxAllocs int // approximate number of allocated values
+}
+
+func DefaultLimits() Limits {
+	return Limits{MaxDepth: DefaultMaxDepth}
+}
+
+// shared by every task of the program
+type usage struct {
+	steps    atomic.Int64
+	allocs   atomic.Int64
+	timedOut atomic.Bool // every task stops, only the first reports it
+}
+
+func (i *Interpreter) SetLimits(limits Limits) {
+	i.limits = limits
+}
+
+func (i *Interpreter) enterCall(paren *Tokens.Token) error {
+	if i.limits.MaxDepth > 0 && i.depth >= i.limits.MaxDepth {
+		Error.ReportRuntimeError(paren, "Stack overflow.")
+		return Error.ErrStackOverflow
+	}
+	i.depth++
+	return nil
+}
+
+func (i *Interpreter) exitCall() {
+	i.depth--
+}
+
+// called once for every executed statement
+func (i *Interpreter) step() error {
+	if i.ctx.Err() != nil {
+		return i.timedOut()
+	}
+	steps := i.usage.steps.Add(1)
+	if i.limits.MaxSteps > 0 && steps > int64(i.limits.MaxSteps) {
+		Error.ReportLimitError("Execution budget exhausted.")
+		return Error.ErrBudgetExhausted
+	}
+	return nil
+}
+
+func (i *Interpreter) timedOut() error {
+	if i.usage.timedOut.CompareAndSwap(false, true) {
+		Error.ReportLimitError("Execution timed out.")
+	}
+	return Error.ErrTimeout
+}
+
+// called for every environment, function and string created at runtime
+func (i *Interpreter) alloc() error {
+	allocs := i.usage.allocs.Add(1)
+	if i.limits.MaxAllocs > 0 && allocs > int64(i.limits.MaxAllocs) {
+		Error.ReportLimitError("Memory limit exceeded.")
+		return Error.ErrMemoryLimit
+	}
+	return nil
+}
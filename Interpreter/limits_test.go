@@ -0,0 +1,62 @@
+package Interpreter_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/AnshVM/golox/Environment"
+	"github.com/AnshVM/golox/Error"
+	"github.com/AnshVM/golox/Interpreter"
+	"github.com/AnshVM/golox/Parser"
+	"github.com/AnshVM/golox/Resolver"
+	"github.com/AnshVM/golox/Scanner"
+)
+
+// Runs a program that nests calls depth deep, returning the error and what
+// was reported
+func runNested(t *testing.T, depth int, limits Interpreter.Limits) (error, string) {
+	t.Helper()
+	var output bytes.Buffer
+	previous := Error.Output
+	Error.Output = &output
+	defer func() { Error.Output, Error.HadError, Error.HadRuntimeError = previous, false, false }()
+
+	source := fmt.Sprintf("fun nest(n) {\n  if (n > 1) nest(n - 1);\n}\nnest(%d);\n", depth)
+	scanner := Scanner.NewScanner(source)
+	stmts := Parser.NewParser(scanner.ScanTokens()).Parse()
+	interpreter := Interpreter.NewInterpreter(&Environment.Environment{Values: map[string]any{}})
+	defer interpreter.Close()
+	interpreter.SetLimits(limits)
+	Resolver.NewResolver(interpreter).Resolve(stmts)
+	if Error.HadError {
+		t.Fatal("the program doesn't resolve")
+	}
+	return interpreter.Interpret(stmts), output.String()
+}
+
+func TestMaxDepth(t *testing.T) {
+	tests := []struct {
+		name     string
+		depth    int
+		maxDepth int
+		err      error
+	}{
+		{"below the limit", 9, 10, nil},
+		{"at the limit", 10, 10, nil},
+		{"over the limit", 11, 10, Error.ErrStackOverflow},
+		{"no limit", 3 * Interpreter.DefaultMaxDepth, 0, nil},
+		{"default limit", Interpreter.DefaultMaxDepth + 1, Interpreter.DefaultMaxDepth, Error.ErrStackOverflow},
+	}
+	for _, test := range tests {
+		err, output := runNested(t, test.depth, Interpreter.Limits{MaxDepth: test.maxDepth})
+		if err != test.err {
+			t.Errorf("%s: error %v, want %v", test.name, err, test.err)
+		}
+		overflowed := strings.Contains(output, "Error at '(': Stack overflow.")
+		if overflowed != (test.err != nil) {
+			t.Errorf("%s: reported %q", test.name, output)
+		}
+	}
+}
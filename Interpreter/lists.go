@@ -0,0 +1,41 @@
+package Interpreter
+
+import "strings"
+
+// List holds the arguments a rest parameter collects, or the ones passed to
+// list(). Lists can't be changed once made
+type List struct {
+	items []any
+}
+
+func (l *List) String() string {
+	items := make([]string, len(l.items))
+	for index, item := range l.items {
+		items[index] = Stringify(item)
+	}
+	return "[" + strings.Join(items, ", ") + "]"
+}
+
+// list(...items) makes a list of its arguments, at(list, index) returns the
+// item at index
+func listNatives() map[string]*LoxCallable {
+	return map[string]*LoxCallable{
+		"list": variadic(0, Variadic, func(i *Interpreter, args []any) (any, error) {
+			if err := i.alloc(); err != nil {
+				return nil, err
+			}
+			return &List{items: append([]any{}, args...)}, nil
+		}),
+		"at": native(2, func(i *Interpreter, args []any) (any, error) {
+			list, ok := args[0].(*List)
+			if !ok {
+				return nil, i.nativeError("at expects a list, got " + Stringify(args[0]) + ".")
+			}
+			index, err := i.indexArg("at", args[1], len(list.items)-1)
+			if err != nil {
+				return nil, err
+			}
+			return list.items[index], nil
+		}),
+	}
+}
@@ -0,0 +1,103 @@
+package Interpreter
+
+import (
+	"fmt"
+
+	"github.com/AnshVM/golox/Ast"
+	"github.com/AnshVM/golox/Environment"
+	"github.com/AnshVM/golox/Error"
+)
+
+func (i *Interpreter) ExecMatchStmt(stmt *Ast.MatchStmt) error {
+	value, err := i.Eval(stmt.Value)
+	if err != nil {
+		return err
+	}
+	for _, c := range stmt.Cases {
+		env, matched, err := i.matchCase(c, value)
+		if err != nil {
+			return err
+		}
+		if !matched {
+			continue
+		}
+		if env == nil {
+			return i.Exec(c.Body)
+		}
+		return i.executeBlock([]Ast.Stmt{c.Body}, env)
+	}
+	if stmt.Default != nil {
+		return i.Exec(stmt.Default)
+	}
+	return nil
+}
+
+// Unlike the statement, a match expression must give a value, so it is a
+// runtime error when no case matches and there is no default
+func (i *Interpreter) EvalMatch(expr *Ast.MatchExpr) (any, error) {
+	value, err := i.Eval(expr.Value)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range expr.Cases {
+		env, matched, err := i.matchCase(c, value)
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			return i.evalIn(c.Result, env)
+		}
+	}
+	if expr.Default != nil {
+		return i.Eval(expr.Default)
+	}
+	Error.ReportRuntimeError(expr.Keyword, fmt.Sprintf("No case matches %s.", Stringify(value)))
+	return nil, Error.ErrRuntimeError
+}
+
+// Tells whether a pattern of c matches value and the guard of c holds. A
+// binding pattern binds value in a new environment, returned for the guard
+// and the body to run in
+func (i *Interpreter) matchCase(c *Ast.MatchCase, value any) (*Environment.Environment, bool, error) {
+	var env *Environment.Environment
+	matched := false
+	for _, pattern := range c.Patterns {
+		switch pattern.Kind {
+		case Ast.LiteralPattern:
+			matched = isEqual(value, pattern.Value)
+		case Ast.WildcardPattern:
+			matched = true
+		case Ast.BindingPattern:
+			if err := i.alloc(); err != nil {
+				return nil, false, err
+			}
+			env = &Environment.Environment{Values: map[string]any{}, Enclosing: i.Env}
+			env.Define(pattern.Token.Lexeme, value)
+			matched = true
+		}
+		if matched {
+			break
+		}
+	}
+	if !matched || c.Guard == nil {
+		return env, matched, nil
+	}
+	guard, err := i.evalIn(c.Guard, env)
+	if err != nil {
+		return nil, false, err
+	}
+	return env, isTruthy(guard), nil
+}
+
+// Evaluates expr in env, or in the current environment when env is nil
+func (i *Interpreter) evalIn(expr Ast.Expr, env *Environment.Environment) (any, error) {
+	if env == nil {
+		return i.Eval(expr)
+	}
+	prev := i.Env
+	defer func() {
+		i.Env = prev
+	}()
+	i.Env = env
+	return i.Eval(expr)
+}
@@ -0,0 +1,104 @@
+package Interpreter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Natives for strings. Lengths and indexes count characters (runes), so
+// "é" has length 1 even though it takes two bytes
+func stringNatives() map[string]*LoxCallable {
+	return map[string]*LoxCallable{
+		// len(list) is the number of items in list
+		"len": native(1, func(i *Interpreter, args []any) (any, error) {
+			if list, ok := args[0].(*List); ok {
+				return float32(len(list.items)), nil
+			}
+			s, err := i.stringArg("len", args[0])
+			if err != nil {
+				return nil, err
+			}
+			return float32(len([]rune(s))), nil
+		}),
+		"charAt": native(2, func(i *Interpreter, args []any) (any, error) {
+			s, err := i.stringArg("charAt", args[0])
+			if err != nil {
+				return nil, err
+			}
+			runes := []rune(s)
+			index, err := i.indexArg("charAt", args[1], len(runes)-1)
+			if err != nil {
+				return nil, err
+			}
+			return string(runes[index]), nil
+		}),
+		// substr(s, start, end) is the part of s from start up to, but not including, end
+		"substr": native(3, func(i *Interpreter, args []any) (any, error) {
+			s, err := i.stringArg("substr", args[0])
+			if err != nil {
+				return nil, err
+			}
+			runes := []rune(s)
+			start, err := i.indexArg("substr", args[1], len(runes))
+			if err != nil {
+				return nil, err
+			}
+			end, err := i.indexArg("substr", args[2], len(runes))
+			if err != nil {
+				return nil, err
+			}
+			if end < start {
+				return nil, i.nativeError("substr end must not be before start.")
+			}
+			if err := i.alloc(); err != nil {
+				return nil, err
+			}
+			return string(runes[start:end]), nil
+		}),
+		// indexOf(s, part) is -1 when part isn't found
+		"indexOf": native(2, func(i *Interpreter, args []any) (any, error) {
+			s, err := i.stringArg("indexOf", args[0])
+			if err != nil {
+				return nil, err
+			}
+			part, err := i.stringArg("indexOf", args[1])
+			if err != nil {
+				return nil, err
+			}
+			index := strings.Index(s, part)
+			if index == -1 {
+				return float32(-1), nil
+			}
+			return float32(len([]rune(s[:index]))), nil
+		}),
+	}
+}
+
+func native(arity uint, call func(i *Interpreter, args []any) (any, error)) *LoxCallable {
+	return variadic(arity, arity, call)
+}
+
+// A native taking from least to most arguments, most can be Variadic
+func variadic(least uint, most uint, call func(i *Interpreter, args []any) (any, error)) *LoxCallable {
+	return &LoxCallable{Arity: func() (uint, uint) { return least, most }, Call: call}
+}
+
+func (i *Interpreter) stringArg(name string, arg any) (string, error) {
+	s, ok := arg.(string)
+	if !ok {
+		return "", i.nativeError(fmt.Sprintf("%s expects a string, got %s.", name, Stringify(arg)))
+	}
+	return s, nil
+}
+
+// a whole number from 0 to max
+func (i *Interpreter) indexArg(name string, arg any, max int) (int, error) {
+	number, ok := arg.(float32)
+	if !ok || number != float32(int(number)) {
+		return 0, i.nativeError(fmt.Sprintf("%s expects a whole number index, got %s.", name, Stringify(arg)))
+	}
+	if number < 0 || int(number) > max {
+		return 0, i.nativeError(fmt.Sprintf("Index %d is out of range for %s.", int(number), name))
+	}
+	return int(number), nil
+}
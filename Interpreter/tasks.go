@@ -0,0 +1,426 @@
+package Interpreter
+
+import (
+	"sync"
+
+	"github.com/AnshVM/golox/Ast"
+	"github.com/AnshVM/golox/Environment"
+	"github.com/AnshVM/golox/Error"
+)
+
+// The scheduler keeps count of the tasks started with spawn and of the ones
+// waiting on a channel or on another task. Once every task waits, none of
+// them can ever wake the others, so they all fail with a deadlock error
+// instead of hanging. One lock guards the scheduler and every channel
+type scheduler struct {
+	mu      sync.Mutex
+	tasks   int // tasks that haven't finished, the main program included
+	blocked map[*waiter]bool
+	running sync.WaitGroup
+	out     sync.Mutex // keeps lines printed by different tasks whole
+	// closed by Close, releases the bodies still paused at a yield or an await
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newScheduler() *scheduler {
+	return &scheduler{tasks: 1, blocked: map[*waiter]bool{}, closed: make(chan struct{})}
+}
+
+// Ends the goroutines of generators nobody finished and of async bodies
+// waiting on a future that never settles, they would stay paused for as
+// long as the process runs. The interpreter can't run code after it. The
+// golox command exits once a script ends, so only the unit test runner and
+// the REPL call it
+func (i *Interpreter) Close() {
+	i.sched.closeOnce.Do(func() { close(i.sched.closed) })
+}
+
+const deadlock = "Deadlock: every task is waiting."
+
+// A task waiting for a channel operation or for another task to finish
+type waiter struct {
+	wake  chan struct{}
+	woken bool
+	index int // the select case that went ahead
+	value any // what a recv received
+	err   string
+}
+
+func newWaiter() *waiter {
+	return &waiter{wake: make(chan struct{}, 1)}
+}
+
+// Wakes w, the scheduler must be locked
+func (s *scheduler) fire(w *waiter, index int) {
+	w.woken = true
+	w.index = index
+	delete(s.blocked, w)
+	w.wake <- struct{}{}
+}
+
+func (s *scheduler) checkDeadlock() {
+	if s.tasks == 0 || len(s.blocked) < s.tasks {
+		return
+	}
+	for w := range s.blocked {
+		w.err = deadlock
+		s.fire(w, w.index)
+	}
+}
+
+// Blocks until w is woken, the scheduler must be locked and stays locked
+// once sleep returns. Returns false when the program was cancelled first
+func (i *Interpreter) sleep(w *waiter) bool {
+	s := i.sched
+	s.blocked[w] = true
+	s.checkDeadlock()
+	s.mu.Unlock()
+	select {
+	case <-w.wake:
+		s.mu.Lock()
+		return true
+	case <-i.ctx.Done():
+		s.mu.Lock()
+		if w.woken {
+			return true
+		}
+		w.woken = true
+		delete(s.blocked, w)
+		return false
+	}
+}
+
+// Channels have no buffer, a send waits for a recv and the other way round
+type Channel struct {
+	closed    bool
+	receivers []*pending
+	senders   []*pending
+}
+
+// A waiter queued on a channel by a recv, a send or a select case
+type pending struct {
+	w     *waiter
+	index int // of the select case, 0 outside a select
+	value any // what a send sends
+}
+
+// Pops the first waiter that nothing else has woken yet
+func nextWaiter(queue *[]*pending) *pending {
+	for len(*queue) > 0 {
+		p := (*queue)[0]
+		*queue = (*queue)[1:]
+		if !p.w.woken {
+			return p
+		}
+	}
+	return nil
+}
+
+func removeWaiter(queue []*pending, w *waiter) []*pending {
+	kept := queue[:0]
+	for _, p := range queue {
+		if p.w != w {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+// Hands value to a waiting recv, false if there is none
+func (s *scheduler) trySend(ch *Channel, value any) bool {
+	receiver := nextWaiter(&ch.receivers)
+	if receiver == nil {
+		return false
+	}
+	receiver.w.value = value
+	s.fire(receiver.w, receiver.index)
+	return true
+}
+
+// Takes a value from a waiting send. A closed channel is always ready and
+// gives nil
+func (s *scheduler) tryRecv(ch *Channel) (value any, ready bool) {
+	if sender := nextWaiter(&ch.senders); sender != nil {
+		s.fire(sender.w, sender.index)
+		return sender.value, true
+	}
+	return nil, ch.closed
+}
+
+func (s *scheduler) close(ch *Channel) {
+	ch.closed = true
+	for receiver := nextWaiter(&ch.receivers); receiver != nil; receiver = nextWaiter(&ch.receivers) {
+		receiver.w.value = nil
+		s.fire(receiver.w, receiver.index)
+	}
+	for sender := nextWaiter(&ch.senders); sender != nil; sender = nextWaiter(&ch.senders) {
+		sender.w.err = "Send on a closed channel."
+		s.fire(sender.w, sender.index)
+	}
+}
+
+// Task is what spawn evaluates to, wait(task) returns what the call returned
+type Task struct {
+	finished bool
+	result   any
+	failed   bool
+	joiners  []*waiter
+}
+
+// chan() makes a channel, send(ch, value) waits for a recv to take value,
+// recv(ch) waits for a send and returns what it sent, or nil once ch is
+// closed, close(ch) wakes every recv waiting on ch. wait(task) waits for
+// the task to finish
+func taskNatives() map[string]*LoxCallable {
+	return map[string]*LoxCallable{
+		"chan": native(0, func(i *Interpreter, _ []any) (any, error) {
+			if err := i.alloc(); err != nil {
+				return nil, err
+			}
+			return &Channel{}, nil
+		}),
+		"send": native(2, func(i *Interpreter, args []any) (any, error) {
+			ch, err := i.channelArg("send", args[0])
+			if err != nil {
+				return nil, err
+			}
+			return nil, i.send(ch, args[1])
+		}),
+		"recv": native(1, func(i *Interpreter, args []any) (any, error) {
+			ch, err := i.channelArg("recv", args[0])
+			if err != nil {
+				return nil, err
+			}
+			return i.recv(ch)
+		}),
+		"close": native(1, func(i *Interpreter, args []any) (any, error) {
+			ch, err := i.channelArg("close", args[0])
+			if err != nil {
+				return nil, err
+			}
+			i.sched.mu.Lock()
+			defer i.sched.mu.Unlock()
+			if ch.closed {
+				return nil, i.nativeError("Channel is already closed.")
+			}
+			i.sched.close(ch)
+			return nil, nil
+		}),
+		"wait": native(1, func(i *Interpreter, args []any) (any, error) {
+			task, ok := args[0].(*Task)
+			if !ok {
+				return nil, i.nativeError("wait expects a task, got " + Stringify(args[0]) + ".")
+			}
+			return i.wait(task)
+		}),
+	}
+}
+
+func (i *Interpreter) channelArg(name string, arg any) (*Channel, error) {
+	ch, ok := arg.(*Channel)
+	if !ok {
+		return nil, i.nativeError(name + " expects a channel, got " + Stringify(arg) + ".")
+	}
+	return ch, nil
+}
+
+func (i *Interpreter) send(ch *Channel, value any) error {
+	s := i.sched
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ch.closed {
+		return i.nativeError("Send on a closed channel.")
+	}
+	if s.trySend(ch, value) {
+		return nil
+	}
+	w := newWaiter()
+	ch.senders = append(ch.senders, &pending{w: w, value: value})
+	return i.await(w)
+}
+
+func (i *Interpreter) recv(ch *Channel) (any, error) {
+	s := i.sched
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if value, ready := s.tryRecv(ch); ready {
+		return value, nil
+	}
+	w := newWaiter()
+	ch.receivers = append(ch.receivers, &pending{w: w})
+	if err := i.await(w); err != nil {
+		return nil, err
+	}
+	return w.value, nil
+}
+
+func (i *Interpreter) wait(task *Task) (any, error) {
+	s := i.sched
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !task.finished {
+		w := newWaiter()
+		task.joiners = append(task.joiners, w)
+		if err := i.await(w); err != nil {
+			return nil, err
+		}
+	}
+	if task.failed {
+		// the task already reported its error
+		return nil, Error.ErrRuntimeError
+	}
+	return task.result, nil
+}
+
+// Sleeps on w and turns what woke it into an error reported at the call
+func (i *Interpreter) await(w *waiter) error {
+	if !i.sleep(w) {
+		return i.timedOut()
+	}
+	if w.err != "" {
+		return i.nativeError(w.err)
+	}
+	return nil
+}
+
+func (i *Interpreter) EvalSpawn(expr *Ast.SpawnExpr) (any, error) {
+	function, args, err := i.prepareCall(expr.Call)
+	if err != nil {
+		return nil, err
+	}
+	if err := i.alloc(); err != nil {
+		return nil, err
+	}
+	task := &Task{}
+	forked := i.fork(i.Env)
+	forked.callSite = expr.Call
+	s := i.sched
+	s.mu.Lock()
+	s.tasks++
+	s.mu.Unlock()
+	s.running.Add(1)
+	go func() {
+		defer s.running.Done()
+		result, err := function.Call(forked, args)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		task.finished = true
+		task.result = result
+		task.failed = err != nil
+		for _, w := range task.joiners {
+			s.fire(w, 0)
+		}
+		task.joiners = nil
+		s.tasks--
+		i.loop.wake(s)
+		s.checkDeadlock()
+	}()
+	return task, nil
+}
+
+// Waits for the tasks the program spawned once the main program is done
+func (i *Interpreter) waitForTasks() {
+	s := i.sched
+	s.mu.Lock()
+	s.tasks--
+	s.checkDeadlock()
+	s.mu.Unlock()
+	s.running.Wait()
+	s.mu.Lock()
+	s.tasks++
+	s.mu.Unlock()
+}
+
+func (i *Interpreter) ExecSelectStmt(stmt *Ast.SelectStmt) error {
+	channels := make([]*Channel, len(stmt.Cases))
+	values := make([]any, len(stmt.Cases))
+	for index, c := range stmt.Cases {
+		value, err := i.Eval(c.Channel)
+		if err != nil {
+			return err
+		}
+		ch, ok := value.(*Channel)
+		if !ok {
+			Error.ReportRuntimeError(c.Op, "Select cases need a channel, got "+Stringify(value)+".")
+			return Error.ErrRuntimeError
+		}
+		channels[index] = ch
+		if c.Value != nil {
+			if values[index], err = i.Eval(c.Value); err != nil {
+				return err
+			}
+		}
+	}
+
+	index, value, err := i.selectCase(stmt, channels, values)
+	if err != nil {
+		return err
+	}
+	if index == -1 {
+		return i.Exec(stmt.Default)
+	}
+	c := stmt.Cases[index]
+	if c.Name == nil {
+		return i.Exec(c.Body)
+	}
+	if err := i.alloc(); err != nil {
+		return err
+	}
+	env := &Environment.Environment{Values: map[string]any{}, Enclosing: i.Env}
+	env.Define(c.Name.Lexeme, value)
+	return i.executeBlock([]Ast.Stmt{c.Body}, env)
+}
+
+// Goes ahead with the first case that is ready, or with the default (-1)
+// when none is, or waits for one
+func (i *Interpreter) selectCase(stmt *Ast.SelectStmt, channels []*Channel, values []any) (int, any, error) {
+	s := i.sched
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for index, c := range stmt.Cases {
+		if c.Value == nil {
+			if value, ready := s.tryRecv(channels[index]); ready {
+				return index, value, nil
+			}
+			continue
+		}
+		if channels[index].closed {
+			Error.ReportRuntimeError(c.Op, "Send on a closed channel.")
+			return 0, nil, Error.ErrRuntimeError
+		}
+		if s.trySend(channels[index], values[index]) {
+			return index, nil, nil
+		}
+	}
+	if stmt.Default != nil {
+		return -1, nil, nil
+	}
+
+	w := newWaiter()
+	for index, c := range stmt.Cases {
+		ch := channels[index]
+		if c.Value == nil {
+			ch.receivers = append(ch.receivers, &pending{w: w, index: index})
+		} else {
+			ch.senders = append(ch.senders, &pending{w: w, index: index, value: values[index]})
+		}
+	}
+	woken := i.sleep(w)
+	for _, ch := range channels {
+		ch.receivers = removeWaiter(ch.receivers, w)
+		ch.senders = removeWaiter(ch.senders, w)
+	}
+	if !woken {
+		return 0, nil, i.timedOut()
+	}
+	if w.err != "" {
+		at := stmt.Keyword
+		if len(stmt.Cases) > 0 && w.err != deadlock {
+			at = stmt.Cases[w.index].Op
+		}
+		Error.ReportRuntimeError(at, w.err)
+		return 0, nil, Error.ErrRuntimeError
+	}
+	return w.index, w.value, nil
+}
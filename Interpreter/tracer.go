@@ -0,0 +1,25 @@
+package Interpreter
+
+import "github.com/AnshVM/golox/Ast"
+
+// Arms of a branch: the then or else side of an if or ?:, and whether
+// the right side of an and/or was evaluated
+const (
+	ThenArm         = 0
+	ElseArm         = 1
+	ShortCircuitArm = 0
+	RightArm        = 1
+)
+
+// Tracer is told about every statement the interpreter executes and every
+// branch it takes, for things like coverage reports
+type Tracer interface {
+	Statement(stmt Ast.Stmt)
+	Branch(node Ast.Node, arm int)
+}
+
+func (i *Interpreter) branch(node Ast.Node, arm int) {
+	if i.Tracer != nil {
+		i.Tracer.Branch(node, arm)
+	}
+}
@@ -0,0 +1,189 @@
+package Linter
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/AnshVM/golox/Ast"
+	"github.com/AnshVM/golox/Scanner"
+	"github.com/AnshVM/golox/Tokens"
+)
+
+type Severity int
+
+const (
+	Off Severity = iota
+	Warning
+	Error
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Warning:
+		return "warning"
+	case Error:
+		return "error"
+	}
+	return "off"
+}
+
+func ParseSeverity(name string) (Severity, error) {
+	switch name {
+	case "off":
+		return Off, nil
+	case "warning":
+		return Warning, nil
+	case "error":
+		return Error, nil
+	}
+	return Off, fmt.Errorf("unknown severity %q, expected off, warning or error", name)
+}
+
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+type Diagnostic struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Line     uint     `json:"line"`
+	Column   uint     `json:"column"`
+	Message  string   `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%d:%d: %s: %s [%s]", d.Line, d.Column, d.Severity, d.Message, d.Rule)
+}
+
+// reports a problem found by a rule at the given token
+type reporter func(token *Tokens.Token, message string)
+
+type Rule struct {
+	Name        string
+	Description string
+	Severity    Severity // used unless the config says otherwise
+	check       func(program []Ast.Stmt, report reporter)
+}
+
+var registry = map[string]*Rule{}
+
+func register(rule *Rule) {
+	registry[rule.Name] = rule
+}
+
+// All registered rules sorted by name
+func Rules() []*Rule {
+	rules := []*Rule{}
+	for _, rule := range registry {
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(a, b int) bool { return rules[a].Name < rules[b].Name })
+	return rules
+}
+
+// Severity overrides by rule name
+type Config map[string]Severity
+
+// Parses "rule=severity"
+func (c Config) Set(setting string) error {
+	name, severity, ok := strings.Cut(setting, "=")
+	if !ok {
+		return fmt.Errorf("expected rule=severity, got %q", setting)
+	}
+	if _, ok := registry[name]; !ok {
+		return fmt.Errorf("unknown rule %q", name)
+	}
+	s, err := ParseSeverity(severity)
+	if err != nil {
+		return err
+	}
+	c[name] = s
+	return nil
+}
+
+func (c Config) String() string {
+	settings := []string{}
+	for name, severity := range c {
+		settings = append(settings, name+"="+severity.String())
+	}
+	sort.Strings(settings)
+	return strings.Join(settings, ",")
+}
+
+// Runs every enabled rule over a parsed program. The source is needed for
+// `// lox:ignore rule` comments, which silence a rule on their own line or,
+// when alone on a line, on the line below
+func Lint(source string, program []Ast.Stmt, config Config) []Diagnostic {
+	ignores := ignoredRules(source)
+	diagnostics := []Diagnostic{}
+	for _, rule := range Rules() {
+		severity := rule.Severity
+		if s, ok := config[rule.Name]; ok {
+			severity = s
+		}
+		if severity == Off {
+			continue
+		}
+		rule.check(program, func(token *Tokens.Token, message string) {
+			if ignores.has(token.Line, rule.Name) {
+				return
+			}
+			diagnostics = append(diagnostics, Diagnostic{
+				Rule:     rule.Name,
+				Severity: severity,
+				Line:     token.Line + 1,
+				Column:   token.Column + 1,
+				Message:  message,
+			})
+		})
+	}
+	sort.SliceStable(diagnostics, func(a, b int) bool {
+		if diagnostics[a].Line != diagnostics[b].Line {
+			return diagnostics[a].Line < diagnostics[b].Line
+		}
+		return diagnostics[a].Column < diagnostics[b].Column
+	})
+	return diagnostics
+}
+
+var ignoreComment = regexp.MustCompile(`^//\s*lox:ignore\b(.*)$`)
+
+// rule names ignored per line, an empty set ignores every rule
+type ignores map[uint]map[string]bool
+
+// Reads the ignore comments from the comments the scanner finds, so `//`
+// inside a string is never taken for one
+func ignoredRules(source string) ignores {
+	scanner := Scanner.NewScanner(source)
+	tokens := scanner.ScanTokens()
+	// lines holding code, to tell comments after code from comments alone
+	code := map[uint]bool{}
+	for _, token := range tokens {
+		for line := token.Line; line <= token.Line+uint(strings.Count(token.Lexeme, "\n")); line++ {
+			code[line] = true
+		}
+	}
+	result := ignores{}
+	for _, comment := range scanner.Comments() {
+		match := ignoreComment.FindStringSubmatch(comment.Lexeme)
+		if match == nil {
+			continue
+		}
+		rules := map[string]bool{}
+		for _, name := range strings.FieldsFunc(match[1], func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+			rules[name] = true
+		}
+		result[comment.Line] = rules
+		if !code[comment.Line] {
+			result[comment.Line+1] = rules
+		}
+	}
+	return result
+}
+
+func (i ignores) has(line uint, rule string) bool {
+	rules, ok := i[line]
+	return ok && (len(rules) == 0 || rules[rule])
+}
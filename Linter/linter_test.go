@@ -0,0 +1,117 @@
+package Linter
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/AnshVM/golox/Parser"
+	"github.com/AnshVM/golox/Scanner"
+)
+
+func lint(t *testing.T, source string, config Config) []string {
+	t.Helper()
+	scanner := Scanner.NewScanner(source)
+	program := Parser.NewParser(scanner.ScanTokens()).Parse()
+	found := []string{}
+	for _, diagnostic := range Lint(source, program, config) {
+		found = append(found, diagnostic.String())
+	}
+	return found
+}
+
+func TestIgnoreComments(t *testing.T) {
+	source := `fun f() {
+  var a = 1;
+  var b = "// lox:ignore";
+  var c = 2; // lox:ignore unused-variable
+  // lox:ignore
+  var d = 3;
+  var e = "${1} // lox:ignore";
+  var g = 4; // lox:ignore empty-block
+}
+f();
+`
+	expected := []string{
+		"2:7: warning: Variable 'a' is declared but never used. [unused-variable]",
+		"3:7: warning: Variable 'b' is declared but never used. [unused-variable]",
+		"7:7: warning: Variable 'e' is declared but never used. [unused-variable]",
+		"8:7: warning: Variable 'g' is declared but never used. [unused-variable]",
+	}
+	if found := lint(t, source, Config{}); !reflect.DeepEqual(found, expected) {
+		t.Errorf("got %q, want %q", found, expected)
+	}
+}
+
+func TestSeverity(t *testing.T) {
+	source := "if (true) {}\n"
+	tests := []struct {
+		settings []string
+		expected []string
+	}{
+		{nil, []string{
+			"1:1: warning: Condition of 'if' is always the same. [constant-condition]",
+			"1:11: warning: Empty block. [empty-block]",
+		}},
+		{[]string{"empty-block=error", "constant-condition=off"}, []string{
+			"1:11: error: Empty block. [empty-block]",
+		}},
+	}
+	for _, test := range tests {
+		config := Config{}
+		for _, setting := range test.settings {
+			if err := config.Set(setting); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if found := lint(t, source, config); !reflect.DeepEqual(found, test.expected) {
+			t.Errorf("%v: got %q, want %q", test.settings, found, test.expected)
+		}
+	}
+}
+
+func TestConfig(t *testing.T) {
+	config := Config{}
+	for _, setting := range []string{"empty-block=warning", "shadowing=off"} {
+		if err := config.Set(setting); err != nil {
+			t.Errorf("Set(%q): %v", setting, err)
+		}
+	}
+	if config.String() != "empty-block=warning,shadowing=off" {
+		t.Errorf("String() = %q", config.String())
+	}
+	errors := map[string]string{
+		"empty-block":       `expected rule=severity, got "empty-block"`,
+		"no-such-rule=off":  `unknown rule "no-such-rule"`,
+		"empty-block=fatal": `unknown severity "fatal", expected off, warning or error`,
+	}
+	for setting, expected := range errors {
+		if err := config.Set(setting); err == nil || err.Error() != expected {
+			t.Errorf("Set(%q) = %v, want %q", setting, err, expected)
+		}
+	}
+}
+
+func TestRules(t *testing.T) {
+	names := []string{}
+	for _, rule := range Rules() {
+		names = append(names, rule.Name)
+		if rule.Description == "" || rule.check == nil {
+			t.Errorf("rule %q has no description or check", rule.Name)
+		}
+		if rule.Severity != Warning {
+			t.Errorf("rule %q is %s by default, want warning", rule.Name, rule.Severity)
+		}
+	}
+	expected := []string{
+		"assignment-in-condition",
+		"constant-condition",
+		"empty-block",
+		"shadowing",
+		"unreachable-code",
+		"unused-parameter",
+		"unused-variable",
+	}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("Rules() = %q, want %q", names, expected)
+	}
+}
@@ -0,0 +1,178 @@
+package Linter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/AnshVM/golox/Ast"
+	"github.com/AnshVM/golox/Tokens"
+)
+
+func init() {
+	register(&Rule{
+		Name:        "unused-variable",
+		Description: "local variable is never read",
+		Severity:    Warning,
+		check:       unusedBindings(false),
+	})
+	register(&Rule{
+		Name:        "unused-parameter",
+		Description: "function parameter is never read",
+		Severity:    Warning,
+		check:       unusedBindings(true),
+	})
+	register(&Rule{
+		Name:        "shadowing",
+		Description: "declaration hides a variable of an enclosing scope",
+		Severity:    Warning,
+		check:       shadowing,
+	})
+	register(&Rule{
+		Name:        "unreachable-code",
+		Description: "statement after a return",
+		Severity:    Warning,
+		check:       unreachableCode,
+	})
+	register(&Rule{
+		Name:        "constant-condition",
+		Description: "condition is a literal, so it always takes the same branch",
+		Severity:    Warning,
+		check:       constantCondition,
+	})
+	register(&Rule{
+		Name:        "assignment-in-condition",
+		Description: "condition is an assignment, probably meant '=='",
+		Severity:    Warning,
+		check:       assignmentInCondition,
+	})
+	register(&Rule{
+		Name:        "empty-block",
+		Description: "block without statements",
+		Severity:    Warning,
+		check:       emptyBlock,
+	})
+}
+
+// names starting with '_' are meant to be unused
+func unusedBindings(params bool) func(program []Ast.Stmt, report reporter) {
+	return func(program []Ast.Stmt, report reporter) {
+		walkScopes(program, nil, func(b *binding) {
+			if b.param != params || b.reads > 0 || strings.HasPrefix(b.name.Lexeme, "_") {
+				return
+			}
+			if params {
+				report(b.name, fmt.Sprintf("Parameter '%s' is never used.", b.name.Lexeme))
+			} else {
+				report(b.name, fmt.Sprintf("Variable '%s' is declared but never used.", b.name.Lexeme))
+			}
+		})
+	}
+}
+
+func shadowing(program []Ast.Stmt, report reporter) {
+	walkScopes(program, func(b *binding, shadowed *binding) {
+		if shadowed != nil {
+			report(b.name, fmt.Sprintf("'%s' shadows the declaration on line %d.", b.name.Lexeme, shadowed.name.Line+1))
+		}
+	}, nil)
+}
+
+func unreachableCode(program []Ast.Stmt, report reporter) {
+	check := func(stmts []Ast.Stmt) {
+		for index, stmt := range stmts {
+			if ret, ok := stmt.(*Ast.Return); ok && index+1 < len(stmts) {
+				report(locate(stmts[index+1], ret.Keyword), "Unreachable code after return.")
+				return
+			}
+		}
+	}
+	Ast.Inspect(program, func(node Ast.Node) bool {
+		switch n := node.(type) {
+		case *Ast.BlockStmt:
+			check(n.Statements)
+		case *Ast.NamedFunction:
+			check(n.Body)
+		case *Ast.AnonymousFuncion:
+			check(n.Body)
+		}
+		return true
+	})
+}
+
+func constantCondition(program []Ast.Stmt, report reporter) {
+	Ast.Inspect(program, func(node Ast.Node) bool {
+		switch n := node.(type) {
+		case *Ast.IfStmt:
+			if isLiteral(n.Condition) {
+				report(locate(n, n.Keyword), "Condition of 'if' is always the same.")
+			}
+		case *Ast.WhileStmt:
+			// while (true) is the usual infinite loop, and what for (;;) becomes
+			if isLiteral(n.Condition) && unwrap(n.Condition).(*Ast.LiteralExpr).Value != true {
+				report(locate(n, n.Keyword), "Condition of 'while' is always the same.")
+			}
+		case *Ast.ConditionalExpr:
+			if isLiteral(n.Condition) {
+				report(locate(n, nil), "Condition of '?:' is always the same.")
+			}
+		}
+		return true
+	})
+}
+
+func assignmentInCondition(program []Ast.Stmt, report reporter) {
+	check := func(condition Ast.Expr) {
+		// wrapping the assignment in another pair of parens marks it as intended
+		if assign, ok := condition.(*Ast.AssignExpr); ok {
+			report(assign.Name, fmt.Sprintf("Assignment to '%s' used as a condition, did you mean '=='?", assign.Name.Lexeme))
+		}
+	}
+	Ast.Inspect(program, func(node Ast.Node) bool {
+		switch n := node.(type) {
+		case *Ast.IfStmt:
+			check(n.Condition)
+		case *Ast.WhileStmt:
+			check(n.Condition)
+		case *Ast.ConditionalExpr:
+			check(n.Condition)
+		}
+		return true
+	})
+}
+
+func emptyBlock(program []Ast.Stmt, report reporter) {
+	Ast.Inspect(program, func(node Ast.Node) bool {
+		// blocks made up by the parser have no brace and are never empty
+		if block, ok := node.(*Ast.BlockStmt); ok && len(block.Statements) == 0 && block.Brace != nil {
+			report(block.Brace, "Empty block.")
+		}
+		return true
+	})
+}
+
+// the first token of a node, or fallback for nodes without tokens. Without
+// either the start of the file is used
+func locate(node Ast.Node, fallback *Tokens.Token) *Tokens.Token {
+	if start, _ := Ast.Span(node); start != nil {
+		return start
+	}
+	if fallback != nil {
+		return fallback
+	}
+	return &Tokens.Token{}
+}
+
+func unwrap(expr Ast.Expr) Ast.Expr {
+	for {
+		group, ok := expr.(*Ast.GroupingExpr)
+		if !ok {
+			return expr
+		}
+		expr = group.Expression
+	}
+}
+
+func isLiteral(expr Ast.Expr) bool {
+	_, ok := unwrap(expr).(*Ast.LiteralExpr)
+	return ok
+}
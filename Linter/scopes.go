@@ -0,0 +1,164 @@
+package Linter
+
+import (
+	"github.com/AnshVM/golox/Ast"
+	"github.com/AnshVM/golox/Tokens"
+)
+
+type binding struct {
+	name  *Tokens.Token
+	param bool
+	reads int
+}
+
+// Tracks declarations and reads the way the resolver does, calling declared
+// for every new binding and done for every local binding once its scope ends
+type scopeWalker struct {
+	scopes   []map[string]*binding
+	declared func(b *binding, shadowed *binding)
+	done     func(b *binding)
+}
+
+func walkScopes(program []Ast.Stmt, declared func(b *binding, shadowed *binding), done func(b *binding)) {
+	w := &scopeWalker{scopes: []map[string]*binding{{}}, declared: declared, done: done}
+	for _, stmt := range program {
+		w.walk(stmt)
+	}
+}
+
+func (w *scopeWalker) walk(node Ast.Node) {
+	switch n := node.(type) {
+	case *Ast.VarStmt:
+		if n.Initializer != nil {
+			w.walk(n.Initializer)
+		}
+		w.declare(n.Name, false)
+		return
+	case *Ast.VariableExpr:
+		if b := w.lookup(n.Name.Lexeme); b != nil {
+			b.reads++
+		}
+		return
+	case *Ast.CompoundAssignExpr:
+		// reads the variable as well as writing it
+		if b := w.lookup(n.Name.Lexeme); b != nil {
+			b.reads++
+		}
+		w.walk(n.Value)
+		return
+	case *Ast.IncrementExpr:
+		if b := w.lookup(n.Name.Lexeme); b != nil {
+			b.reads++
+		}
+		return
+	case *Ast.BlockStmt:
+		w.beginScope()
+		for _, stmt := range n.Statements {
+			w.walk(stmt)
+		}
+		w.endScope()
+		return
+	case *Ast.NamedFunction:
+		w.declare(n.Name, false)
+		w.function(n.Params, n.Defaults, n.Body)
+		return
+	case *Ast.AnonymousFuncion:
+		w.function(n.Params, n.Defaults, n.Body)
+		return
+	case *Ast.TestStmt:
+		w.function(nil, nil, n.Body)
+		return
+	case *Ast.MatchStmt:
+		w.walk(n.Value)
+		for _, c := range n.Cases {
+			w.matchCase(c, c.Body)
+		}
+		w.walk(n.Default)
+		return
+	case *Ast.MatchExpr:
+		w.walk(n.Value)
+		for _, c := range n.Cases {
+			w.matchCase(c, c.Result)
+		}
+		w.walk(n.Default)
+		return
+	case *Ast.SelectStmt:
+		for _, c := range n.Cases {
+			w.walk(c.Channel)
+			w.walk(c.Value)
+		}
+		for _, c := range n.Cases {
+			w.beginScope()
+			if c.Name != nil {
+				w.declare(c.Name, false)
+			}
+			w.walk(c.Body)
+			w.endScope()
+		}
+		w.walk(n.Default)
+		return
+	}
+	for _, child := range Ast.Children(node) {
+		w.walk(child)
+	}
+}
+
+func (w *scopeWalker) function(params []*Tokens.Token, defaults []Ast.Expr, body []Ast.Stmt) {
+	w.beginScope()
+	for index, param := range params {
+		if index < len(defaults) {
+			w.walk(defaults[index])
+		}
+		w.declare(param, true)
+	}
+	for _, stmt := range body {
+		w.walk(stmt)
+	}
+	w.endScope()
+}
+
+func (w *scopeWalker) matchCase(c *Ast.MatchCase, body Ast.Node) {
+	w.beginScope()
+	for _, pattern := range c.Patterns {
+		if pattern.Kind == Ast.BindingPattern {
+			w.declare(pattern.Token, false)
+		}
+	}
+	w.walk(c.Guard)
+	w.walk(body)
+	w.endScope()
+}
+
+func (w *scopeWalker) declare(name *Tokens.Token, param bool) {
+	b := &binding{name: name, param: param}
+	var shadowed *binding
+	for i := len(w.scopes) - 2; i >= 0 && shadowed == nil; i-- {
+		shadowed = w.scopes[i][name.Lexeme]
+	}
+	if w.declared != nil {
+		w.declared(b, shadowed)
+	}
+	w.scopes[len(w.scopes)-1][name.Lexeme] = b
+}
+
+func (w *scopeWalker) lookup(name string) *binding {
+	for i := len(w.scopes) - 1; i >= 0; i-- {
+		if b, ok := w.scopes[i][name]; ok {
+			return b
+		}
+	}
+	return nil
+}
+
+func (w *scopeWalker) beginScope() {
+	w.scopes = append(w.scopes, map[string]*binding{})
+}
+
+func (w *scopeWalker) endScope() {
+	if w.done != nil {
+		for _, b := range w.scopes[len(w.scopes)-1] {
+			w.done(b)
+		}
+	}
+	w.scopes = w.scopes[:len(w.scopes)-1]
+}
@@ -0,0 +1,287 @@
+package Optimizer
+
+import (
+	"math"
+
+	"github.com/AnshVM/golox/Ast"
+	"github.com/AnshVM/golox/Tokens"
+)
+
+// Folds expressions made of literals and removes branches that can never run.
+// It runs after the resolver, so it never removes or replaces a variable or
+// assignment, and never adds or removes a scope: the depths recorded by the
+// resolver stay valid. Operations that fail at runtime, like dividing by
+// zero or adding a string to a number, are left for the interpreter to report
+func Optimize(stmts []Ast.Stmt) []Ast.Stmt {
+	return optimizeList(stmts)
+}
+
+func optimizeList(stmts []Ast.Stmt) []Ast.Stmt {
+	optimized := []Ast.Stmt{}
+	for _, stmt := range stmts {
+		if s := optimizeStmt(stmt); s != nil {
+			optimized = append(optimized, s)
+		}
+		// nothing after a return in the same block can run
+		if _, ok := stmt.(*Ast.Return); ok {
+			break
+		}
+	}
+	return optimized
+}
+
+// Returns nil when the statement can be dropped
+func optimizeStmt(stmt Ast.Stmt) Ast.Stmt {
+	switch s := stmt.(type) {
+	case *Ast.ExpressionStmt:
+		s.Expression = optimizeExpr(s.Expression)
+	case *Ast.PrintStmt:
+		s.Expression = optimizeExpr(s.Expression)
+	case *Ast.VarStmt:
+		if s.Initializer != nil {
+			s.Initializer = optimizeExpr(s.Initializer)
+		}
+	case *Ast.BlockStmt:
+		s.Statements = optimizeList(s.Statements)
+	case *Ast.IfStmt:
+		s.Condition = optimizeExpr(s.Condition)
+		if value, ok := literal(s.Condition); ok {
+			if truthy(value) {
+				return optimizeStmt(s.ThenBranch)
+			}
+			if s.ElseBranch != nil {
+				return optimizeStmt(s.ElseBranch)
+			}
+			return nil
+		}
+		s.ThenBranch = orEmpty(optimizeStmt(s.ThenBranch))
+		if s.ElseBranch != nil {
+			s.ElseBranch = optimizeStmt(s.ElseBranch)
+		}
+	case *Ast.WhileStmt:
+		s.Condition = optimizeExpr(s.Condition)
+		if value, ok := literal(s.Condition); ok {
+			if !truthy(value) {
+				return nil
+			}
+			// loops forever without evaluating a condition, like for (;;)
+			s.Condition = nil
+		}
+		s.Body = orEmpty(optimizeStmt(s.Body))
+	case *Ast.NamedFunction:
+		optimizeDefaults(s.Defaults)
+		s.Body = optimizeList(s.Body)
+	case *Ast.TestStmt:
+		s.Body = optimizeList(s.Body)
+	case *Ast.Return:
+		if s.Value != nil {
+			s.Value = optimizeExpr(s.Value)
+		}
+	case *Ast.YieldStmt:
+		if s.Value != nil {
+			s.Value = optimizeExpr(s.Value)
+		}
+	case *Ast.MatchStmt:
+		s.Value = optimizeExpr(s.Value)
+		for _, c := range s.Cases {
+			optimizeGuard(c)
+			c.Body = orEmpty(optimizeStmt(c.Body))
+		}
+		if s.Default != nil {
+			s.Default = orEmpty(optimizeStmt(s.Default))
+		}
+	case *Ast.SelectStmt:
+		for _, c := range s.Cases {
+			c.Channel = optimizeExpr(c.Channel)
+			if c.Value != nil {
+				c.Value = optimizeExpr(c.Value)
+			}
+			c.Body = orEmpty(optimizeStmt(c.Body))
+		}
+		if s.Default != nil {
+			s.Default = orEmpty(optimizeStmt(s.Default))
+		}
+	}
+	return stmt
+}
+
+func optimizeGuard(c *Ast.MatchCase) {
+	if c.Guard != nil {
+		c.Guard = optimizeExpr(c.Guard)
+	}
+}
+
+// a statement that can't be dropped, like the body of a loop
+func orEmpty(stmt Ast.Stmt) Ast.Stmt {
+	if stmt == nil {
+		return &Ast.BlockStmt{Statements: []Ast.Stmt{}}
+	}
+	return stmt
+}
+
+func optimizeExpr(expr Ast.Expr) Ast.Expr {
+	switch e := expr.(type) {
+	case *Ast.GroupingExpr:
+		e.Expression = optimizeExpr(e.Expression)
+		if _, ok := literal(e.Expression); ok {
+			return e.Expression
+		}
+	case *Ast.UnaryExpr:
+		e.Right = optimizeExpr(e.Right)
+		if value, ok := literal(e.Right); ok {
+			switch e.Operator.Type {
+			case Tokens.BANG:
+				return &Ast.LiteralExpr{Value: !truthy(value)}
+			case Tokens.MINUS:
+				if number, ok := value.(float32); ok {
+					return &Ast.LiteralExpr{Value: -number}
+				}
+			}
+		}
+	case *Ast.BinaryExpr:
+		e.Left = optimizeExpr(e.Left)
+		e.Right = optimizeExpr(e.Right)
+		if folded, ok := foldBinary(e); ok {
+			return &Ast.LiteralExpr{Value: folded}
+		}
+	case *Ast.LogicalExpr:
+		e.Left = optimizeExpr(e.Left)
+		e.Right = optimizeExpr(e.Right)
+		if value, ok := literal(e.Left); ok {
+			// `or` stops at a truthy left side, `and` at a falsy one
+			if truthy(value) == (e.Operator.Type == Tokens.OR) {
+				return e.Left
+			}
+			return e.Right
+		}
+	case *Ast.ConditionalExpr:
+		e.Condition = optimizeExpr(e.Condition)
+		e.Then = optimizeExpr(e.Then)
+		e.Else = optimizeExpr(e.Else)
+		if value, ok := literal(e.Condition); ok {
+			if truthy(value) {
+				return e.Then
+			}
+			return e.Else
+		}
+	case *Ast.AssignExpr:
+		e.Value = optimizeExpr(e.Value)
+	case *Ast.CompoundAssignExpr:
+		e.Value = optimizeExpr(e.Value)
+	case *Ast.GetExpr:
+		e.Object = optimizeExpr(e.Object)
+	case *Ast.SpawnExpr:
+		optimizeExpr(e.Call)
+	case *Ast.AwaitExpr:
+		e.Value = optimizeExpr(e.Value)
+	case *Ast.MatchExpr:
+		e.Value = optimizeExpr(e.Value)
+		for _, c := range e.Cases {
+			optimizeGuard(c)
+			c.Result = optimizeExpr(c.Result)
+		}
+		if e.Default != nil {
+			e.Default = optimizeExpr(e.Default)
+		}
+	case *Ast.Call:
+		e.Callee = optimizeExpr(e.Callee)
+		for index, arg := range e.Arguments {
+			e.Arguments[index] = optimizeExpr(arg)
+		}
+	case *Ast.InterpolationExpr:
+		for index, part := range e.Parts {
+			e.Parts[index] = optimizeExpr(part)
+		}
+	case *Ast.AnonymousFuncion:
+		optimizeDefaults(e.Defaults)
+		e.Body = optimizeList(e.Body)
+	}
+	return expr
+}
+
+func optimizeDefaults(defaults []Ast.Expr) {
+	for index, value := range defaults {
+		if value != nil {
+			defaults[index] = optimizeExpr(value)
+		}
+	}
+}
+
+// Arithmetic, comparison, equality and concatenation of literals
+func foldBinary(e *Ast.BinaryExpr) (any, bool) {
+	left, ok := literal(e.Left)
+	if !ok {
+		return nil, false
+	}
+	right, ok := literal(e.Right)
+	if !ok {
+		return nil, false
+	}
+	// literals are equal only when they have the same type and value
+	switch e.Operator.Type {
+	case Tokens.EQUAL_EQUAL:
+		return left == right, true
+	case Tokens.BANG_EQUAL:
+		return left != right, true
+	}
+
+	if l, ok := left.(string); ok {
+		if r, ok := right.(string); ok && e.Operator.Type == Tokens.PLUS {
+			return l + r, true
+		}
+		return nil, false
+	}
+	l, ok := left.(float32)
+	if !ok {
+		return nil, false
+	}
+	r, ok := right.(float32)
+	if !ok {
+		return nil, false
+	}
+	switch e.Operator.Type {
+	case Tokens.PLUS:
+		return l + r, true
+	case Tokens.MINUS:
+		return l - r, true
+	case Tokens.STAR:
+		return l * r, true
+	case Tokens.SLASH:
+		if r == 0 {
+			return nil, false
+		}
+		return l / r, true
+	case Tokens.PERCENT:
+		if r == 0 {
+			return nil, false
+		}
+		return float32(math.Mod(float64(l), float64(r))), true
+	case Tokens.GREATER:
+		return l > r, true
+	case Tokens.GREATER_EQUAL:
+		return l >= r, true
+	case Tokens.LESS:
+		return l < r, true
+	case Tokens.LESS_EQAUL:
+		return l <= r, true
+	}
+	return nil, false
+}
+
+func literal(expr Ast.Expr) (any, bool) {
+	if l, ok := expr.(*Ast.LiteralExpr); ok {
+		return l.Value, true
+	}
+	return nil, false
+}
+
+// same rules as the interpreter, only nil and false are falsy
+func truthy(value any) bool {
+	if value == nil {
+		return false
+	}
+	if b, ok := value.(bool); ok {
+		return b
+	}
+	return true
+}
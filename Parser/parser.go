@@ -16,6 +16,8 @@ type Parser struct {
 	tokens     []*Token
 	current    uint
 	parseError error
+	// one entry per function being parsed, set once its body yields
+	yields []bool
 }
 
 func NewParser(tokens []*Tokens.Token) *Parser {
@@ -51,8 +53,15 @@ func (p *Parser) declaration() Stmt {
 	switch true {
 	case p.match(Tokens.VAR):
 		return p.varDecl()
+	case p.match(Tokens.CONST, Tokens.LET):
+		return p.constDecl()
 	case p.match(Tokens.FUN):
 		return p.funcDecl()
+	case p.match(Tokens.ASYNC):
+		return p.asyncDecl()
+	case p.atTest():
+		p.advance()
+		return p.testDecl()
 	default:
 		return p.statement()
 	}
@@ -70,45 +79,144 @@ func (p *Parser) function() Stmt {
 	}
 }
 
-func (p *Parser) paramList(paren *Tokens.Token, kind string) []*Tokens.Token {
-	var paramList []*Tokens.Token
+// asyncDecl -> "async" "fun" IDENTIFIER "(" params? ")" block
+func (p *Parser) asyncDecl() Stmt {
+	p.consume(Tokens.FUN, "Expect 'fun' after 'async'.")
+	name := p.consume(Tokens.IDENTIFIER, "Expect function name.")
+	if name == nil {
+		return nil
+	}
+	stmt := p.namedFunction(name, "function")
+	stmt.(*Ast.NamedFunction).IsAsync = true
+	return stmt
+}
+
+// `test` is only a keyword in front of a test name, so programs can still
+// use it as a name
+func (p *Parser) atTest() bool {
+	return p.check(Tokens.IDENTIFIER) && p.peek().Lexeme == "test" && p.checkNext(Tokens.STRING)
+}
+
+// testDecl -> "test" STRING block
+func (p *Parser) testDecl() Stmt {
+	keyword := p.previous()
+	name := p.consume(Tokens.STRING, "Expect test name after 'test'.")
+	p.consume(Tokens.LEFT_BRACE, "Expect '{' before test body.")
+	return &Ast.TestStmt{Keyword: keyword, Name: name, Body: p.block()}
+}
+
+// The parameters of a function. Types and defaults have one optional entry
+// per name, the last name collects the extra arguments when isVariadic
+type parameters struct {
+	names      []*Tokens.Token
+	types      []*Tokens.Token
+	defaults   []Expr
+	isVariadic bool
+}
+
+func (p *Parser) paramList(paren *Tokens.Token, kind string) parameters {
+	params := parameters{names: []*Tokens.Token{}, types: []*Tokens.Token{}, defaults: []Expr{}}
 	if p.peek().Type != Tokens.RIGHT_PAREN {
-		paramList = p.params()
+		params = p.params()
 	}
-	if len(paramList) >= 255 {
+	if len(params.names) >= 255 {
 		Error.ReportParseError(paren, "Can't have more than 255 arguments")
 		p.parseError = Error.ErrParseError
 	}
 	p.consume(Tokens.RIGHT_PAREN, fmt.Sprintf("Expect ')' after %s declaration", kind))
-	return paramList
+	return params
 }
 
 func (p *Parser) anonymousFunction(kind string) Expr {
 	paren := p.consume(Tokens.LEFT_PAREN, fmt.Sprintf("Expect '(' after fun"))
 	params := p.paramList(paren, "function")
+	returnType := p.optionalType()
 	p.consume(Tokens.LEFT_BRACE, fmt.Sprintf("Expect '{' before %s body", kind))
-	stmts := p.block()
-	return &Ast.AnonymousFuncion{Params: params, Body: stmts}
+	stmts, isGenerator := p.functionBody()
+	return &Ast.AnonymousFuncion{
+		Params:      params.names,
+		ParamTypes:  params.types,
+		Defaults:    params.defaults,
+		ReturnType:  returnType,
+		Body:        stmts,
+		IsGenerator: isGenerator,
+		IsVariadic:  params.isVariadic,
+	}
 }
 
 func (p *Parser) namedFunction(name *Token, kind string) Stmt {
 	paren := p.consume(Tokens.LEFT_PAREN, fmt.Sprintf("Expect '(' after %s name", kind))
 	params := p.paramList(paren, "function")
+	returnType := p.optionalType()
 	p.consume(Tokens.LEFT_BRACE, fmt.Sprintf("Expect '{' before %s body", kind))
+	stmts, isGenerator := p.functionBody()
+	return &Ast.NamedFunction{
+		Name:        name,
+		Params:      params.names,
+		ParamTypes:  params.types,
+		Defaults:    params.defaults,
+		ReturnType:  returnType,
+		Body:        stmts,
+		IsGenerator: isGenerator,
+		IsVariadic:  params.isVariadic,
+	}
+}
+
+// Parses the block of a function, and tells whether it yields. A yield in a
+// function nested inside doesn't count
+func (p *Parser) functionBody() ([]Stmt, bool) {
+	p.yields = append(p.yields, false)
 	stmts := p.block()
-	return &Ast.NamedFunction{Name: name, Params: params, Body: stmts}
+	isGenerator := p.yields[len(p.yields)-1]
+	p.yields = p.yields[:len(p.yields)-1]
+	return stmts, isGenerator
 }
 
-func (p *Parser) params() []*Tokens.Token {
-	paramList := []*Tokens.Token{}
+// params -> param ( "," param )*
+// param -> "..."? IDENTIFIER typeAnnotation ( "=" expression )?
+func (p *Parser) params() parameters {
+	params := parameters{}
+	hasDefault := false
 	for {
+		if params.isVariadic {
+			Error.ReportParseError(params.names[len(params.names)-1], "A rest parameter must be the last parameter.")
+			p.parseError = Error.ErrParseError
+		}
+		params.isVariadic = p.match(Tokens.ELLIPSIS)
 		param := p.consume(Tokens.IDENTIFIER, "Expect parameter name.")
-		paramList = append(paramList, param)
+		params.names = append(params.names, param)
+		params.types = append(params.types, p.optionalType())
+		var value Expr
+		if p.match(Tokens.EQUAL) {
+			if params.isVariadic {
+				Error.ReportParseError(p.previous(), "A rest parameter can't have a default value.")
+				p.parseError = Error.ErrParseError
+			}
+			value = p.expression()
+			hasDefault = true
+		} else if hasDefault && !params.isVariadic && param != nil {
+			Error.ReportParseError(param, "Expect a default value for a parameter after one with a default value.")
+			p.parseError = Error.ErrParseError
+		}
+		params.defaults = append(params.defaults, value)
 		if !p.match(Tokens.COMMA) {
 			break
 		}
 	}
-	return paramList
+	return params
+}
+
+// typeAnnotation -> ( ":" ( IDENTIFIER | "nil" ) )?
+func (p *Parser) optionalType() *Tokens.Token {
+	if !p.match(Tokens.COLON) {
+		return nil
+	}
+	if p.match(Tokens.IDENTIFIER, Tokens.NIL) {
+		return p.previous()
+	}
+	Error.ReportParseError(p.peek(), "Expect type name after ':'.")
+	p.parseError = Error.ErrParseError
+	return nil
 }
 
 func (p *Parser) varDecl() Stmt {
@@ -116,13 +224,30 @@ func (p *Parser) varDecl() Stmt {
 	if varName == nil {
 		return nil
 	}
+	varType := p.optionalType()
 	if p.match(Tokens.EQUAL) {
 		expr := p.expression()
 		p.consume(Tokens.SEMICOLON, "Expect ';' after declaration")
-		return &Ast.VarStmt{Name: varName, Initializer: expr}
+		return &Ast.VarStmt{Name: varName, Type: varType, Initializer: expr}
 	}
 	p.consume(Tokens.SEMICOLON, "Expect ';' after declaration")
-	return &Ast.VarStmt{Name: varName, Initializer: nil}
+	return &Ast.VarStmt{Name: varName, Type: varType, Initializer: nil}
+}
+
+// constDecl -> ( "const" | "let" ) IDENTIFIER typeAnnotation "=" expression ";"
+func (p *Parser) constDecl() Stmt {
+	keyword := p.previous()
+	name := p.consume(Tokens.IDENTIFIER, "Expected constant name")
+	if name == nil {
+		return nil
+	}
+	constType := p.optionalType()
+	if p.consume(Tokens.EQUAL, fmt.Sprintf("Expect '=' after constant name, a %s needs a value.", keyword.Lexeme)) == nil {
+		return nil
+	}
+	expr := p.expression()
+	p.consume(Tokens.SEMICOLON, "Expect ';' after declaration")
+	return &Ast.VarStmt{Name: name, Type: constType, Initializer: expr, IsConst: true}
 }
 
 func (p *Parser) statement() Stmt {
@@ -130,7 +255,8 @@ func (p *Parser) statement() Stmt {
 	case p.match(Tokens.PRINT):
 		return p.print()
 	case p.match(Tokens.LEFT_BRACE):
-		return &Ast.BlockStmt{Statements: p.block()}
+		brace := p.previous()
+		return &Ast.BlockStmt{Brace: brace, Statements: p.block()}
 	case p.match(Tokens.IF):
 		return p.ifStmt()
 	case p.match(Tokens.WHILE):
@@ -139,6 +265,12 @@ func (p *Parser) statement() Stmt {
 		return p.ForStmt()
 	case p.match(Tokens.RETURN):
 		return p.ReturnStmt()
+	case p.match(Tokens.YIELD):
+		return p.yieldStmt()
+	case p.match(Tokens.SELECT):
+		return p.selectStmt()
+	case p.match(Tokens.MATCH):
+		return p.matchStmt()
 	default:
 		return p.expressionStmt()
 	}
@@ -154,8 +286,175 @@ func (p *Parser) ReturnStmt() Stmt {
 	return &Ast.Return{Keyword: keyword, Value: expr}
 }
 
+// yieldStmt -> "yield" expression? ";"
+// the resolver reports a yield outside of a function
+func (p *Parser) yieldStmt() Stmt {
+	keyword := p.previous()
+	if len(p.yields) > 0 {
+		p.yields[len(p.yields)-1] = true
+	}
+	if p.match(Tokens.SEMICOLON) {
+		return &Ast.YieldStmt{Keyword: keyword, Value: nil}
+	}
+	expr := p.expression()
+	p.consume(Tokens.SEMICOLON, "Expect ';' after yield.")
+	return &Ast.YieldStmt{Keyword: keyword, Value: expr}
+}
+
+// selectStmt -> "select" "{" selectCase* ( "default" "=>" statement )? "}"
+// selectCase -> "case" ( IDENTIFIER "=" )? ( recvOp | sendOp ) "=>" statement
+// recvOp -> "recv" "(" expression ")", sendOp -> "send" "(" expression "," expression ")"
+func (p *Parser) selectStmt() Stmt {
+	stmt := &Ast.SelectStmt{Keyword: p.previous(), Cases: []*Ast.SelectCase{}}
+	p.consume(Tokens.LEFT_BRACE, "Expect '{' after 'select'.")
+	for !p.check(Tokens.RIGHT_BRACE) && !p.isAtEnd() && p.parseError == nil {
+		if p.match(Tokens.DEFAULT) {
+			if stmt.Default != nil {
+				Error.ReportParseError(p.previous(), "A select can only have one default.")
+				p.parseError = Error.ErrParseError
+			}
+			p.consume(Tokens.ARROW, "Expect '=>' after 'default'.")
+			stmt.Default = p.statement()
+			continue
+		}
+		p.consume(Tokens.CASE, "Expect 'case' or 'default' in select.")
+		stmt.Cases = append(stmt.Cases, p.selectCase())
+	}
+	p.consume(Tokens.RIGHT_BRACE, "Expect '}' after select cases.")
+	return stmt
+}
+
+func (p *Parser) selectCase() *Ast.SelectCase {
+	c := &Ast.SelectCase{Keyword: p.previous()}
+	if p.check(Tokens.IDENTIFIER) && p.tokens[p.current+1].Type == Tokens.EQUAL {
+		c.Name = p.advance()
+		p.advance()
+	}
+	c.Op = p.consume(Tokens.IDENTIFIER, "Expect recv or send after 'case'.")
+	if c.Op == nil {
+		return c
+	}
+	if c.Op.Lexeme != "recv" && c.Op.Lexeme != "send" {
+		Error.ReportParseError(c.Op, "Expect recv or send after 'case'.")
+		p.parseError = Error.ErrParseError
+		return c
+	}
+	p.consume(Tokens.LEFT_PAREN, fmt.Sprintf("Expect '(' after '%s'.", c.Op.Lexeme))
+	c.Channel = p.expression()
+	if c.Op.Lexeme == "send" {
+		p.consume(Tokens.COMMA, "Expect ',' after channel.")
+		c.Value = p.expression()
+		if c.Name != nil {
+			Error.ReportParseError(c.Name, "Only a recv case can assign the value it receives.")
+			p.parseError = Error.ErrParseError
+		}
+	}
+	p.consume(Tokens.RIGHT_PAREN, "Expect ')' after select case.")
+	p.consume(Tokens.ARROW, "Expect '=>' after select case.")
+	c.Body = p.statement()
+	return c
+}
+
+// matchStmt -> "match" "(" expression ")" "{" matchCase* ( "default" "=>" statement )? "}"
+// matchCase -> "case" pattern ( "," pattern )* ( "if" expression )? "=>" statement
+func (p *Parser) matchStmt() Stmt {
+	stmt := &Ast.MatchStmt{Keyword: p.previous(), Value: p.matchValue()}
+	stmt.Cases = p.matchCases(func(c *Ast.MatchCase) {
+		c.Body = p.statement()
+	}, func() {
+		stmt.Default = p.statement()
+	})
+	return stmt
+}
+
+// the expression form has an expression and a ';' after each '=>'
+func (p *Parser) matchExpr() Expr {
+	expr := &Ast.MatchExpr{Keyword: p.previous(), Value: p.matchValue()}
+	expr.Cases = p.matchCases(func(c *Ast.MatchCase) {
+		c.Result = p.expression()
+		p.consume(Tokens.SEMICOLON, "Expect ';' after match case.")
+	}, func() {
+		expr.Default = p.expression()
+		p.consume(Tokens.SEMICOLON, "Expect ';' after match case.")
+	})
+	return expr
+}
+
+func (p *Parser) matchValue() Expr {
+	p.consume(Tokens.LEFT_PAREN, "Expect '(' after 'match'.")
+	value := p.expression()
+	p.consume(Tokens.RIGHT_PAREN, "Expect ')' after match value.")
+	p.consume(Tokens.LEFT_BRACE, "Expect '{' before match cases.")
+	return value
+}
+
+// Parses cases up to the closing brace, arm parses what follows the '=>'
+// of a case and defaultArm what follows the one of the default
+func (p *Parser) matchCases(arm func(c *Ast.MatchCase), defaultArm func()) []*Ast.MatchCase {
+	cases := []*Ast.MatchCase{}
+	hasDefault := false
+	for !p.check(Tokens.RIGHT_BRACE) && !p.isAtEnd() && p.parseError == nil {
+		if p.match(Tokens.DEFAULT) {
+			if hasDefault {
+				Error.ReportParseError(p.previous(), "A match can only have one default.")
+				p.parseError = Error.ErrParseError
+			}
+			hasDefault = true
+			p.consume(Tokens.ARROW, "Expect '=>' after 'default'.")
+			defaultArm()
+			continue
+		}
+		p.consume(Tokens.CASE, "Expect 'case' or 'default' in match.")
+		c := &Ast.MatchCase{Keyword: p.previous(), Patterns: []*Ast.Pattern{p.pattern()}}
+		for p.match(Tokens.COMMA) {
+			c.Patterns = append(c.Patterns, p.pattern())
+		}
+		if p.match(Tokens.IF) {
+			c.Guard = p.expression()
+		}
+		p.consume(Tokens.ARROW, "Expect '=>' after match case.")
+		arm(c)
+		cases = append(cases, c)
+	}
+	if p.parseError == nil {
+		p.consume(Tokens.RIGHT_BRACE, "Expect '}' after match cases.")
+	}
+	return cases
+}
+
+// pattern -> NUMBER | "-" NUMBER | STRING | "true" | "false" | "nil" | IDENTIFIER
+// the IDENTIFIER _ is the wildcard, any other binds the value
+func (p *Parser) pattern() *Ast.Pattern {
+	switch {
+	case p.match(Tokens.NUMBER, Tokens.STRING):
+		return &Ast.Pattern{Kind: Ast.LiteralPattern, Token: p.previous(), Value: p.previous().Literal}
+	case p.match(Tokens.MINUS):
+		minus := p.previous()
+		number := p.consume(Tokens.NUMBER, "Expect a number after '-' in a pattern.")
+		if number == nil {
+			return &Ast.Pattern{Kind: Ast.WildcardPattern, Token: minus}
+		}
+		return &Ast.Pattern{Kind: Ast.LiteralPattern, Token: minus, Value: -number.Literal.(float32)}
+	case p.match(Tokens.TRUE):
+		return &Ast.Pattern{Kind: Ast.LiteralPattern, Token: p.previous(), Value: true}
+	case p.match(Tokens.FALSE):
+		return &Ast.Pattern{Kind: Ast.LiteralPattern, Token: p.previous(), Value: false}
+	case p.match(Tokens.NIL):
+		return &Ast.Pattern{Kind: Ast.LiteralPattern, Token: p.previous(), Value: nil}
+	case p.match(Tokens.IDENTIFIER):
+		if p.previous().Lexeme == "_" {
+			return &Ast.Pattern{Kind: Ast.WildcardPattern, Token: p.previous()}
+		}
+		return &Ast.Pattern{Kind: Ast.BindingPattern, Token: p.previous()}
+	}
+	Error.ReportParseError(p.peek(), "Expect a pattern after 'case'.")
+	p.parseError = Error.ErrParseError
+	return &Ast.Pattern{Kind: Ast.WildcardPattern, Token: p.peek()}
+}
+
 // desugarises to While loop
 func (p *Parser) ForStmt() Stmt {
+	keyword := p.previous()
 	p.consume(Tokens.LEFT_PAREN, "Expect '(' after 'for'.")
 
 	var initializer Stmt
@@ -187,7 +486,7 @@ func (p *Parser) ForStmt() Stmt {
 	if condition == nil {
 		condition = &Ast.LiteralExpr{Value: true}
 	}
-	body = &Ast.WhileStmt{Condition: condition, Body: body}
+	body = &Ast.WhileStmt{Keyword: keyword, Condition: condition, Body: body}
 	if initializer != nil {
 		body = &Ast.BlockStmt{Statements: []Stmt{initializer, body}}
 	}
@@ -195,14 +494,16 @@ func (p *Parser) ForStmt() Stmt {
 }
 
 func (p *Parser) WhileStmt() Stmt {
+	keyword := p.previous()
 	p.consume(Tokens.LEFT_PAREN, "Expect '(' after 'while'.")
 	expr := p.expression()
 	p.consume(Tokens.RIGHT_PAREN, "Expect ')' after expression")
 	body := p.statement()
-	return &Ast.WhileStmt{Condition: expr, Body: body}
+	return &Ast.WhileStmt{Keyword: keyword, Condition: expr, Body: body}
 }
 
 func (p *Parser) ifStmt() Stmt {
+	keyword := p.previous()
 	p.consume(Tokens.LEFT_PAREN, "Expect '(' after 'if'.")
 	condition := p.expression()
 	p.consume(Tokens.RIGHT_PAREN, "Expect ')' after expression.")
@@ -212,13 +513,14 @@ func (p *Parser) ifStmt() Stmt {
 	if p.match(Tokens.ELSE) {
 		elseBranch = p.statement()
 	}
-	return &Ast.IfStmt{Condition: condition, ThenBranch: thenBranch, ElseBranch: elseBranch}
+	return &Ast.IfStmt{Keyword: keyword, Condition: condition, ThenBranch: thenBranch, ElseBranch: elseBranch}
 }
 
 func (p *Parser) print() Stmt {
+	keyword := p.previous()
 	expr := p.expression()
 	p.consume(Tokens.SEMICOLON, "Expect ';' after expression")
-	return &Ast.PrintStmt{Expression: expr}
+	return &Ast.PrintStmt{Keyword: keyword, Expression: expr}
 }
 
 func (p *Parser) block() []Stmt {
@@ -240,7 +542,7 @@ func (p *Parser) expression() Expr {
 	return p.assignment()
 }
 
-// assignment -> IDENTIFIER "=" assignment | equality
+// assignment -> IDENTIFIER ( "=" | "+=" | "-=" | "*=" | "/=" | "%=" ) assignment | equality
 func (p *Parser) assignment() Expr {
 	expr := p.funcExpr()
 	if p.match(Tokens.EQUAL) {
@@ -251,18 +553,41 @@ func (p *Parser) assignment() Expr {
 		}
 		Error.ReportParseError(equals, "Invalid assignment target")
 	}
+	if p.match(Tokens.PLUS_EQUAL, Tokens.MINUS_EQUAL, Tokens.STAR_EQUAL, Tokens.SLASH_EQUAL, Tokens.PERCENT_EQUAL) {
+		operator := p.previous()
+		value := p.assignment()
+		if varExpr, ok := expr.(*Ast.VariableExpr); ok {
+			return &Ast.CompoundAssignExpr{Name: varExpr.Name, Operator: operator, Value: value}
+		}
+		Error.ReportParseError(operator, "Invalid assignment target")
+	}
 	return expr
 }
 
 func (p *Parser) funcExpr() Expr {
 	if p.match(Tokens.FUN) {
-		paren := p.consume(Tokens.LEFT_PAREN, "Expect '(' after fun")
-		params := p.paramList(paren, "function")
-		p.consume(Tokens.LEFT_BRACE, fmt.Sprintf("Expect '{' before %s body", "function"))
-		stmts := p.block()
-		return &Ast.AnonymousFuncion{Params: params, Body: stmts}
+		return p.anonymousFunction("function")
 	}
-	return p.logic_or()
+	if p.match(Tokens.ASYNC) {
+		p.consume(Tokens.FUN, "Expect 'fun' after 'async'.")
+		expr := p.anonymousFunction("function")
+		expr.(*Ast.AnonymousFuncion).IsAsync = true
+		return expr
+	}
+	return p.conditional()
+}
+
+// conditional -> logic_or ( "?" expression ":" funcExpr )?
+// the else side recurses, so a ? b : c ? d : e is a ? b : (c ? d : e)
+func (p *Parser) conditional() Expr {
+	expr := p.logic_or()
+	if p.match(Tokens.QUESTION_MARK) {
+		then := p.expression()
+		p.consume(Tokens.COLON, "Expect ':' after then branch of conditional expression.")
+		otherwise := p.funcExpr()
+		expr = &Ast.ConditionalExpr{Condition: expr, Then: then, Else: otherwise}
+	}
+	return expr
 }
 
 func (p *Parser) logic_or() Expr {
@@ -335,15 +660,9 @@ func (p *Parser) comparision() Expr {
 
 func (p *Parser) term() Expr {
 
-	switch true {
-	case p.match(Tokens.MINUS):
-		p.missingExpressionBefore("-")
-		break
-	case p.match(Tokens.PLUS):
+	// a leading minus is a negation, parsed by unary
+	if p.match(Tokens.PLUS) {
 		p.missingExpressionBefore("+")
-		break
-	default:
-		break
 	}
 
 	expr := p.factor()
@@ -365,12 +684,15 @@ func (p *Parser) factor() Expr {
 	case p.match(Tokens.STAR):
 		p.missingExpressionBefore("*")
 		break
+	case p.match(Tokens.PERCENT):
+		p.missingExpressionBefore("%")
+		break
 	default:
 		break
 	}
 	expr := p.unary()
 
-	for p.match(Tokens.SLASH, Tokens.STAR) {
+	for p.match(Tokens.SLASH, Tokens.STAR, Tokens.PERCENT) {
 		operator := p.previous()
 		right := p.unary()
 		expr = &Ast.BinaryExpr{Left: expr, Operator: operator, Right: right}
@@ -387,54 +709,102 @@ func (p *Parser) unary() Expr {
 		expr = &Ast.UnaryExpr{Operator: prefix, Right: right}
 		return expr
 	}
-	return p.call()
+	if p.match(Tokens.SPAWN) {
+		return p.spawn()
+	}
+	if p.match(Tokens.AWAIT) {
+		keyword := p.previous()
+		return &Ast.AwaitExpr{Keyword: keyword, Value: p.unary()}
+	}
+	if p.match(Tokens.PLUS_PLUS, Tokens.MINUS_MINUS) {
+		operator := p.previous()
+		return p.increment(p.unary(), operator, true)
+	}
+	return p.postfix()
+}
+
+// postfix -> call ( "++" | "--" )?
+func (p *Parser) postfix() Expr {
+	expr := p.call()
+	if p.match(Tokens.PLUS_PLUS, Tokens.MINUS_MINUS) {
+		return p.increment(expr, p.previous(), false)
+	}
+	return expr
+}
+
+func (p *Parser) increment(target Expr, operator *Token, isPrefix bool) Expr {
+	varExpr, ok := target.(*Ast.VariableExpr)
+	if !ok {
+		Error.ReportParseError(operator, "Invalid increment target.")
+		p.parseError = Error.ErrParseError
+		return target
+	}
+	return &Ast.IncrementExpr{Name: varExpr.Name, Operator: operator, IsPrefix: isPrefix}
+}
+
+// spawn -> "spawn" call
+func (p *Parser) spawn() Expr {
+	keyword := p.previous()
+	expr := p.call()
+	call, ok := expr.(*Ast.Call)
+	if !ok {
+		Error.ReportParseError(keyword, "Expect a function call after 'spawn'.")
+		p.parseError = Error.ErrParseError
+		return nil
+	}
+	return &Ast.SpawnExpr{Keyword: keyword, Call: call}
 }
 
 func (p *Parser) call() Expr {
 	expr := p.primary()
-	for p.match(Tokens.LEFT_PAREN) {
+	for p.match(Tokens.LEFT_PAREN, Tokens.DOT) {
 		token := p.previous()
+		if token.Type == Tokens.DOT {
+			name := p.consume(Tokens.IDENTIFIER, "Expect property name after '.'.")
+			expr = &Ast.GetExpr{Object: expr, Name: name}
+			continue
+		}
 		if p.match(Tokens.RIGHT_PAREN) { //no args
 			expr = &Ast.Call{Callee: expr, Arguments: []Expr{}, Paren: token}
 			continue
 		}
-		args := []Expr{}
-		for {
-			arg := p.expression()
-			args = append(args, arg)
-			if !p.match(Tokens.COMMA) {
-				break
-			}
-		}
+		args, names := p.arguments()
 		p.consume(Tokens.RIGHT_PAREN, "Expect ')' for function call.")
 
 		if len(args) >= 255 {
 			Error.ReportParseError(p.peek(), "Can't have more that 255 arguments")
 		}
-		expr = &Ast.Call{Callee: expr, Arguments: args, Paren: token}
+		expr = &Ast.Call{Callee: expr, Arguments: args, Paren: token, Names: names}
 	}
 	return expr
 }
 
 func (p *Parser) primary() Expr {
 	if p.match(Tokens.NUMBER, Tokens.STRING) {
-		return &Ast.LiteralExpr{Value: p.previous().Literal}
+		return &Ast.LiteralExpr{Value: p.previous().Literal, Token: p.previous()}
+	}
+	if p.match(Tokens.INTERPOLATION) {
+		return p.interpolation()
 	}
 
 	if p.match(Tokens.TRUE) {
-		return &Ast.LiteralExpr{Value: true}
+		return &Ast.LiteralExpr{Value: true, Token: p.previous()}
 	}
 	if p.match(Tokens.FALSE) {
-		return &Ast.LiteralExpr{Value: false}
+		return &Ast.LiteralExpr{Value: false, Token: p.previous()}
 	}
 	if p.match(Tokens.NIL) {
-		return &Ast.LiteralExpr{Value: nil}
+		return &Ast.LiteralExpr{Value: nil, Token: p.previous()}
 	}
 
 	if p.match(Tokens.IDENTIFIER) {
 		return &Ast.VariableExpr{Name: p.previous()}
 	}
 
+	if p.match(Tokens.MATCH) {
+		return p.matchExpr()
+	}
+
 	if p.match(Tokens.LEFT_PAREN) {
 		expr := p.expression()
 		p.consume(Tokens.RIGHT_PAREN, "Expect ')' after expression")
@@ -445,6 +815,23 @@ func (p *Parser) primary() Expr {
 	return nil
 }
 
+// interpolation -> ( INTERPOLATION expression )+ STRING
+func (p *Parser) interpolation() Expr {
+	parts := []Expr{}
+	for {
+		parts = append(parts, &Ast.LiteralExpr{Value: p.previous().Literal, Token: p.previous()})
+		parts = append(parts, p.expression())
+		if !p.match(Tokens.INTERPOLATION) {
+			break
+		}
+	}
+	end := p.consume(Tokens.STRING, "Expect '}' after interpolated expression.")
+	if end != nil {
+		parts = append(parts, &Ast.LiteralExpr{Value: end.Literal, Token: end})
+	}
+	return &Ast.InterpolationExpr{Parts: parts}
+}
+
 func (p *Parser) synchronize() {
 	p.advance()
 
@@ -502,6 +889,35 @@ func (p *Parser) consume(tokenType string, message string) *Tokens.Token {
 	return nil
 }
 
+// arguments -> argument ( "," argument )*
+// argument -> ( IDENTIFIER ":" )? expression
+// names is nil unless an argument is named, named arguments come last
+func (p *Parser) arguments() ([]Expr, []*Tokens.Token) {
+	args := []Expr{}
+	names := []*Tokens.Token{}
+	named := false
+	for {
+		var name *Tokens.Token
+		if p.check(Tokens.IDENTIFIER) && p.checkNext(Tokens.COLON) {
+			name = p.advance()
+			p.advance()
+			named = true
+		} else if named {
+			Error.ReportParseError(p.peek(), "Expect a named argument after named arguments.")
+			p.parseError = Error.ErrParseError
+		}
+		args = append(args, p.expression())
+		names = append(names, name)
+		if !p.match(Tokens.COMMA) {
+			break
+		}
+	}
+	if !named {
+		return args, nil
+	}
+	return args, names
+}
+
 // checks the type of the current token, does not consume
 func (p *Parser) check(tokenType string) bool {
 	if p.isAtEnd() {
@@ -510,6 +926,14 @@ func (p *Parser) check(tokenType string) bool {
 	return p.peek().Type == tokenType
 }
 
+// Whether the token after the current one has type tokenType
+func (p *Parser) checkNext(tokenType string) bool {
+	if p.isAtEnd() {
+		return false
+	}
+	return p.tokens[p.current+1].Type == tokenType
+}
+
 func (p *Parser) isAtEnd() bool {
 	return p.peek().Type == Tokens.EOF
 }
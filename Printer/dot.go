@@ -0,0 +1,98 @@
+package Printer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Renders a node, or a []Ast.Stmt, as a Graphviz digraph
+func Dot(node any, depths Depths) string {
+	var b strings.Builder
+	b.WriteString("digraph ast {\n")
+	b.WriteString("  node [shape=box, fontname=\"monospace\"];\n")
+	next := 0
+
+	var emit func(m map[string]any) string
+	emit = func(m map[string]any) string {
+		id := fmt.Sprintf("n%d", next)
+		next++
+
+		keys := []string{}
+		for key := range m {
+			if key != "kind" && key != "span" {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+
+		label := []string{fmt.Sprint(m["kind"])}
+		if span, ok := m["span"].(map[string]any); ok {
+			start := span["start"].(map[string]uint)
+			label = append(label, fmt.Sprintf("line %d:%d", start["line"], start["column"]))
+		}
+		edges := []string{}
+		for _, key := range keys {
+			switch v := m[key].(type) {
+			case map[string]any:
+				edges = append(edges, fmt.Sprintf("  %s -> %s [label=%s];\n", id, emit(v), quote(key)))
+			case []any:
+				if isNodeList(v) {
+					for index, item := range v {
+						child := emit(item.(map[string]any))
+						edges = append(edges, fmt.Sprintf("  %s -> %s [label=%s];\n", id, child, quote(fmt.Sprintf("%s[%d]", key, index))))
+					}
+				} else {
+					label = append(label, fmt.Sprintf("%s: %s", key, scalar(v)))
+				}
+			default:
+				label = append(label, fmt.Sprintf("%s: %s", key, scalar(v)))
+			}
+		}
+		b.WriteString(fmt.Sprintf("  %s [label=%s];\n", id, quote(strings.Join(label, "\n"))))
+		for _, edge := range edges {
+			b.WriteString(edge)
+		}
+		return id
+	}
+
+	if m, ok := toMap(node, depths).(map[string]any); ok {
+		emit(m)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func isNodeList(list []any) bool {
+	if len(list) == 0 {
+		return false
+	}
+	for _, item := range list {
+		if _, ok := item.(map[string]any); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func scalar(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "nil"
+	case string:
+		return fmt.Sprintf("%q", v)
+	case []any:
+		parts := []string{}
+		for _, item := range v {
+			parts = append(parts, scalar(item))
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	}
+	return fmt.Sprint(value)
+}
+
+func quote(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\\\")
+	s = strings.ReplaceAll(s, "\"", "\\\"")
+	return "\"" + strings.ReplaceAll(s, "\n", "\\n") + "\""
+}
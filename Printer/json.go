@@ -0,0 +1,87 @@
+package Printer
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/AnshVM/golox/Ast"
+	"github.com/AnshVM/golox/Tokens"
+)
+
+// Reports how many scopes away the variable used by expr was resolved,
+// false for globals. Interpreter.Depth satisfies it
+type Depths func(expr Ast.Expr) (int, bool)
+
+// Dumps a node, or a []Ast.Stmt, as JSON with the node kinds, source spans
+// and resolver depths. This is a debugging view of the resolved tree: keys
+// follow the Serializer ("kind", "type" for annotations) but nodes are named
+// after their Go types and tokens are shown as lexemes, so it can't be read
+// back. Serializer.Marshal writes the versioned format in docs/ast-schema.md
+func Json(node any, depths Depths) ([]byte, error) {
+	return json.MarshalIndent(toMap(node, depths), "", "  ")
+}
+
+func toMap(node any, depths Depths) any {
+	if stmts, ok := node.([]Ast.Stmt); ok {
+		return map[string]any{"kind": "Program", "body": fieldValue(stmts, depths)}
+	}
+	v := reflect.ValueOf(node)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	v = v.Elem()
+	m := map[string]any{"kind": v.Type().Name()}
+	if start, end := Ast.Span(node); start != nil {
+		m["span"] = map[string]any{
+			"start": position(start.Line, start.Column),
+			"end":   position(end.Line, end.Column+uint(utf8.RuneCountInString(end.Lexeme))),
+		}
+	}
+	if expr, ok := node.(Ast.Expr); ok && depths != nil {
+		if depth, ok := depths(expr); ok {
+			m["depth"] = depth
+		}
+	}
+	for f := 0; f < v.NumField(); f++ {
+		m[fieldName(v.Type().Field(f).Name)] = fieldValue(v.Field(f).Interface(), depths)
+	}
+	return m
+}
+
+func fieldValue(field any, depths Depths) any {
+	switch f := field.(type) {
+	case *Tokens.Token:
+		if f == nil {
+			return nil
+		}
+		return f.Lexeme
+	case Ast.Expr, Ast.Stmt:
+		return toMap(f, depths)
+	}
+	v := reflect.ValueOf(field)
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !v.IsNil() && v.Elem().Kind() == reflect.Struct {
+			return toMap(field, depths)
+		}
+		return nil
+	case reflect.Slice:
+		list := []any{}
+		for i := 0; i < v.Len(); i++ {
+			list = append(list, fieldValue(v.Index(i).Interface(), depths))
+		}
+		return list
+	}
+	return field
+}
+
+// lines and columns are shown starting from 1, like in error messages
+func position(line uint, column uint) map[string]uint {
+	return map[string]uint{"line": line + 1, "column": column + 1}
+}
+
+func fieldName(name string) string {
+	return strings.ToLower(name[:1]) + name[1:]
+}
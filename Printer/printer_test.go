@@ -0,0 +1,127 @@
+package Printer
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/AnshVM/golox/Ast"
+	"github.com/AnshVM/golox/Parser"
+	"github.com/AnshVM/golox/Scanner"
+)
+
+func parse(t *testing.T, source string) []Ast.Stmt {
+	t.Helper()
+	scanner := Scanner.NewScanner(source)
+	return Parser.NewParser(scanner.ScanTokens()).Parse()
+}
+
+// b is resolved in the scope it is used in, every other variable is global
+func depths(expr Ast.Expr) (int, bool) {
+	if v, ok := expr.(*Ast.VariableExpr); ok && v.Name.Lexeme == "b" {
+		return 0, true
+	}
+	return 0, false
+}
+
+func TestSexpr(t *testing.T) {
+	tests := map[string]string{
+		"fun add(a, b = 1, ...rest) { return a + b; }\nadd(1, b: 2);":                        "(program (fun add (a (= b 1) ...rest) (return (+ a b))) (; (call add 1 (b: 2))))",
+		"var x = cond ? 1 : -2; x += 3; x++; print \"a${x}b\";":                              `(program (var x (?: cond 1 (- 2))) (; (+= x 3)) (; (x ++)) (print (interpolate "a" x "b")))`,
+		"match (v) { case 1, 2 => print 1; case x if x > 2 => print x; case _ => print 0; }": "(program (match v (case (1 2) (print 1)) (case (x) (if (> x 2)) (print x)) (case (_) (print 0))))",
+		"const k = 1; while (k < 2 and j) { j = j % 2; }":                                    "(program (const k 1) (while (and (< k 2) j) (block (; (= j (% j 2))))))",
+	}
+	for source, expected := range tests {
+		if got := Sexpr(parse(t, source)); got != expected {
+			t.Errorf("Sexpr(%q) =\n%s\nwant\n%s", source, got, expected)
+		}
+	}
+}
+
+func TestIndentedSexpr(t *testing.T) {
+	source := `fun outer() {
+  fun inner(n) {
+    if (n > 0) { return inner(n - 1); } else { return "done with the recursion"; }
+  }
+  return inner;
+}
+print 1;`
+	expected := `(program
+  (fun
+    outer
+    ()
+    (fun
+      inner
+      (n)
+      (if
+        (> n 0)
+        (block (return (call inner (- n 1))))
+        (block (return "done with the recursion"))))
+    (return inner))
+  (print 1))`
+	if got := IndentedSexpr(parse(t, source)); got != expected {
+		t.Errorf("IndentedSexpr =\n%s\nwant\n%s", got, expected)
+	}
+}
+
+func TestJson(t *testing.T) {
+	out, err := Json(parse(t, "var a: number = 1;\n{ var b = a; print b; }\n"), depths)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var program map[string]any
+	if err := json.Unmarshal(out, &program); err != nil {
+		t.Fatal(err)
+	}
+	body := program["body"].([]any)
+	declaration := body[0].(map[string]any)
+	block := body[1].(map[string]any)
+	printStmt := block["statements"].([]any)[1].(map[string]any)
+	checks := []struct {
+		name     string
+		got      any
+		expected any
+	}{
+		{"program kind", program["kind"], "Program"},
+		{"declaration kind", declaration["kind"], "VarStmt"},
+		{"annotation", declaration["type"], "number"},
+		{"declaration span", declaration["span"], map[string]any{
+			"start": map[string]any{"line": 1.0, "column": 5.0},
+			"end":   map[string]any{"line": 1.0, "column": 18.0},
+		}},
+		{"initializer value", declaration["initializer"].(map[string]any)["value"], 1.0},
+		{"block kind", block["kind"], "BlockStmt"},
+		{"local depth", printStmt["expression"].(map[string]any)["depth"], 0.0},
+		{"global depth", block["statements"].([]any)[0].(map[string]any)["initializer"].(map[string]any)["depth"], nil},
+	}
+	for _, check := range checks {
+		if !reflect.DeepEqual(check.got, check.expected) {
+			t.Errorf("%s: got %v, want %v", check.name, check.got, check.expected)
+		}
+	}
+}
+
+func TestDot(t *testing.T) {
+	expected := `digraph ast {
+  node [shape=box, fontname="monospace"];
+  n2 [label="LiteralExpr\nline 1:17\ntoken: \"1\"\nvalue: 1"];
+  n1 [label="VarStmt\nline 1:5\nisConst: false\nname: \"a\"\ntype: \"number\""];
+  n1 -> n2 [label="initializer"];
+  n5 [label="VariableExpr\nline 2:11\nname: \"a\""];
+  n4 [label="VarStmt\nline 2:7\nisConst: false\nname: \"b\"\ntype: nil"];
+  n4 -> n5 [label="initializer"];
+  n7 [label="VariableExpr\nline 2:20\ndepth: 0\nname: \"b\""];
+  n6 [label="PrintStmt\nline 2:14\nkeyword: \"print\""];
+  n6 -> n7 [label="expression"];
+  n3 [label="BlockStmt\nline 2:1\nbrace: \"{\""];
+  n3 -> n4 [label="statements[0]"];
+  n3 -> n6 [label="statements[1]"];
+  n0 [label="Program"];
+  n0 -> n1 [label="body[0]"];
+  n0 -> n3 [label="body[1]"];
+}
+`
+	if got := Dot(parse(t, "var a: number = 1;\n{ var b = a; print b; }\n"), depths); got != expected {
+		t.Errorf("Dot =\n%s\nwant\n%s", got, expected)
+	}
+}
@@ -0,0 +1,261 @@
+package Printer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/AnshVM/golox/Ast"
+	"github.com/AnshVM/golox/Tokens"
+)
+
+// an s-expression is either an atom (string) or a list of s-expressions
+type sexpr []any
+
+// Prints a node, or a []Ast.Stmt, as a single line s-expression
+func Sexpr(node any) string {
+	return compact(build(node))
+}
+
+func compact(s any) string {
+	list, ok := s.(sexpr)
+	if !ok {
+		return s.(string)
+	}
+	parts := []string{}
+	for _, item := range list {
+		parts = append(parts, compact(item))
+	}
+	return "(" + strings.Join(parts, " ") + ")"
+}
+
+func build(node any) any {
+	switch n := node.(type) {
+	case []Ast.Stmt:
+		list := sexpr{"program"}
+		return append(list, buildAll(n)...)
+	case *Ast.ExpressionStmt:
+		return sexpr{";", build(n.Expression)}
+	case *Ast.PrintStmt:
+		return sexpr{"print", build(n.Expression)}
+	case *Ast.VarStmt:
+		if n.Initializer == nil {
+			return sexpr{"var", annotated(n.Name.Lexeme, n.Type)}
+		}
+		if n.IsConst {
+			return sexpr{"const", annotated(n.Name.Lexeme, n.Type), build(n.Initializer)}
+		}
+		return sexpr{"var", annotated(n.Name.Lexeme, n.Type), build(n.Initializer)}
+	case *Ast.BlockStmt:
+		list := sexpr{"block"}
+		return append(list, buildAll(n.Statements)...)
+	case *Ast.IfStmt:
+		if n.ElseBranch == nil {
+			return sexpr{"if", build(n.Condition), build(n.ThenBranch)}
+		}
+		return sexpr{"if", build(n.Condition), build(n.ThenBranch), build(n.ElseBranch)}
+	case *Ast.WhileStmt:
+		if n.Condition == nil {
+			return sexpr{"loop", build(n.Body)}
+		}
+		return sexpr{"while", build(n.Condition), build(n.Body)}
+	case *Ast.NamedFunction:
+		list := sexpr{funKeyword(n.IsGenerator, n.IsAsync), annotated(n.Name.Lexeme, n.ReturnType), params(n.Params, n.ParamTypes, n.Defaults, n.IsVariadic)}
+		return append(list, buildAll(n.Body)...)
+	case *Ast.TestStmt:
+		list := sexpr{"test", literal(n.Name.Literal)}
+		return append(list, buildAll(n.Body)...)
+	case *Ast.Return:
+		if n.Value == nil {
+			return sexpr{"return"}
+		}
+		return sexpr{"return", build(n.Value)}
+	case *Ast.YieldStmt:
+		if n.Value == nil {
+			return sexpr{"yield"}
+		}
+		return sexpr{"yield", build(n.Value)}
+	case *Ast.MatchStmt:
+		list := sexpr{"match", build(n.Value)}
+		for _, c := range n.Cases {
+			list = append(list, matchCase(c, build(c.Body)))
+		}
+		if n.Default != nil {
+			list = append(list, sexpr{"default", build(n.Default)})
+		}
+		return list
+	case *Ast.MatchExpr:
+		list := sexpr{"match", build(n.Value)}
+		for _, c := range n.Cases {
+			list = append(list, matchCase(c, build(c.Result)))
+		}
+		if n.Default != nil {
+			list = append(list, sexpr{"default", build(n.Default)})
+		}
+		return list
+	case *Ast.SelectStmt:
+		list := sexpr{"select"}
+		for _, c := range n.Cases {
+			op := sexpr{c.Op.Lexeme, build(c.Channel)}
+			if c.Value != nil {
+				op = append(op, build(c.Value))
+			}
+			if c.Name != nil {
+				list = append(list, sexpr{"case", sexpr{"=", c.Name.Lexeme, op}, build(c.Body)})
+			} else {
+				list = append(list, sexpr{"case", op, build(c.Body)})
+			}
+		}
+		if n.Default != nil {
+			list = append(list, sexpr{"default", build(n.Default)})
+		}
+		return list
+	case *Ast.ConditionalExpr:
+		return sexpr{"?:", build(n.Condition), build(n.Then), build(n.Else)}
+	case *Ast.BinaryExpr:
+		return sexpr{n.Operator.Lexeme, build(n.Left), build(n.Right)}
+	case *Ast.LogicalExpr:
+		return sexpr{n.Operator.Lexeme, build(n.Left), build(n.Right)}
+	case *Ast.GroupingExpr:
+		return sexpr{"group", build(n.Expression)}
+	case *Ast.LiteralExpr:
+		return literal(n.Value)
+	case *Ast.UnaryExpr:
+		return sexpr{n.Operator.Lexeme, build(n.Right)}
+	case *Ast.VariableExpr:
+		return n.Name.Lexeme
+	case *Ast.AssignExpr:
+		return sexpr{"=", n.Name.Lexeme, build(n.Value)}
+	case *Ast.CompoundAssignExpr:
+		return sexpr{n.Operator.Lexeme, n.Name.Lexeme, build(n.Value)}
+	case *Ast.IncrementExpr:
+		// (++ x) is ++x and (x ++) is x++
+		if n.IsPrefix {
+			return sexpr{n.Operator.Lexeme, n.Name.Lexeme}
+		}
+		return sexpr{n.Name.Lexeme, n.Operator.Lexeme}
+	case *Ast.GetExpr:
+		return sexpr{".", build(n.Object), n.Name.Lexeme}
+	case *Ast.SpawnExpr:
+		return sexpr{"spawn", build(n.Call)}
+	case *Ast.AwaitExpr:
+		return sexpr{"await", build(n.Value)}
+	case *Ast.Call:
+		list := sexpr{"call", build(n.Callee)}
+		for index, arg := range n.Arguments {
+			if index < len(n.Names) && n.Names[index] != nil {
+				list = append(list, sexpr{n.Names[index].Lexeme + ":", build(arg)})
+				continue
+			}
+			list = append(list, build(arg))
+		}
+		return list
+	case *Ast.InterpolationExpr:
+		list := sexpr{"interpolate"}
+		for _, part := range n.Parts {
+			list = append(list, build(part))
+		}
+		return list
+	case *Ast.AnonymousFuncion:
+		list := sexpr{annotated(funKeyword(n.IsGenerator, n.IsAsync), n.ReturnType), params(n.Params, n.ParamTypes, n.Defaults, n.IsVariadic)}
+		return append(list, buildAll(n.Body)...)
+	}
+	return "?"
+}
+
+// (case (patterns...) (if guard) body), without the guard if there is none
+func matchCase(c *Ast.MatchCase, body any) sexpr {
+	patterns := sexpr{}
+	for _, pattern := range c.Patterns {
+		if pattern.Kind == Ast.LiteralPattern {
+			patterns = append(patterns, literal(pattern.Value))
+		} else {
+			patterns = append(patterns, pattern.Token.Lexeme)
+		}
+	}
+	list := sexpr{"case", patterns}
+	if c.Guard != nil {
+		list = append(list, sexpr{"if", build(c.Guard)})
+	}
+	return append(list, body)
+}
+
+// generators are marked fun*, async functions async fun
+func funKeyword(isGenerator bool, isAsync bool) string {
+	if isAsync {
+		return "async fun"
+	}
+	if isGenerator {
+		return "fun*"
+	}
+	return "fun"
+}
+
+func buildAll(stmts []Ast.Stmt) []any {
+	list := []any{}
+	for _, stmt := range stmts {
+		list = append(list, build(stmt))
+	}
+	return list
+}
+
+// a param with a default is (= name value), a rest param is ...name
+func params(tokens []*Tokens.Token, types []*Tokens.Token, defaults []Ast.Expr, isVariadic bool) sexpr {
+	list := sexpr{}
+	for index, param := range tokens {
+		var paramType *Tokens.Token
+		if index < len(types) {
+			paramType = types[index]
+		}
+		name := param.Lexeme
+		if isVariadic && index == len(tokens)-1 {
+			name = "..." + name
+		}
+		if index < len(defaults) && defaults[index] != nil {
+			list = append(list, sexpr{"=", annotated(name, paramType), build(defaults[index])})
+			continue
+		}
+		list = append(list, annotated(name, paramType))
+	}
+	return list
+}
+
+// name:type when there is a type annotation
+func annotated(name string, annotation *Tokens.Token) string {
+	if annotation == nil {
+		return name
+	}
+	return name + ":" + annotation.Lexeme
+}
+
+func literal(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "nil"
+	case string:
+		return fmt.Sprintf("%q", v)
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+const lineWidth = 72
+
+// Prints a node, or a []Ast.Stmt, as an s-expression that is broken over
+// several indented lines when it doesn't fit on one
+func IndentedSexpr(node any) string {
+	return indent(build(node), 0)
+}
+
+func indent(s any, level int) string {
+	flat := compact(s)
+	list, ok := s.(sexpr)
+	if !ok || len(list) == 0 || len(flat)+2*level <= lineWidth {
+		return flat
+	}
+	var b strings.Builder
+	b.WriteString("(" + compact(list[0]))
+	for _, item := range list[1:] {
+		b.WriteString("\n" + strings.Repeat("  ", level+1) + indent(item, level+1))
+	}
+	b.WriteString(")")
+	return b.String()
+}
@@ -2,6 +2,7 @@ package Resolver
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/AnshVM/golox/Ast"
 	"github.com/AnshVM/golox/Error"
@@ -12,7 +13,9 @@ import (
 
 const (
 	FUNCTION = iota
-	NONE     = iota
+	GENERATOR
+	ASYNC
+	NONE
 )
 
 const (
@@ -33,16 +36,23 @@ func isUsed(status int) bool {
 	return status == USED
 }
 
+type variable struct {
+	name     *Tokens.Token
+	status   int
+	param    bool
+	constant bool // declared with const or let
+}
+
 type Resolver struct {
 	interpreter     *Interpreter.Interpreter
-	scopes          Utils.Stack[map[string]int]
+	scopes          Utils.Stack[map[string]*variable]
 	currentFunction int
 }
 
 func NewResolver(interpreter *Interpreter.Interpreter) *Resolver {
 	return &Resolver{
 		interpreter:     interpreter,
-		scopes:          Utils.NewStack[map[string]int](),
+		scopes:          Utils.NewStack[map[string]*variable](),
 		currentFunction: NONE,
 	}
 }
@@ -66,11 +76,14 @@ func (r *Resolver) Resolve(node any) {
 			r.Resolve(n.Initializer)
 		}
 		r.define(n.Name)
+		if scope, err := r.scopes.Peek(); err == nil && n.IsConst {
+			scope[n.Name.Lexeme].constant = true
+		}
 		break
 	case *Ast.VariableExpr:
 		scope, err := r.scopes.Peek()
 		if err == nil {
-			if status, ok := scope[n.Name.Lexeme]; ok && status == DECLARED {
+			if v, ok := scope[n.Name.Lexeme]; ok && v.status == DECLARED {
 				Error.ReportParseError(n.Name, "Can't read local variable in its own initializer.")
 			}
 		}
@@ -78,7 +91,14 @@ func (r *Resolver) Resolve(node any) {
 		break
 	case *Ast.AssignExpr:
 		r.Resolve(n.Value)
-		r.resolveLocal(n, n.Name)
+		r.resolveAssign(n, n.Name)
+		break
+	case *Ast.CompoundAssignExpr:
+		r.Resolve(n.Value)
+		r.resolveAssign(n, n.Name)
+		break
+	case *Ast.IncrementExpr:
+		r.resolveAssign(n, n.Name)
 		break
 
 	case *Ast.NamedFunction:
@@ -89,17 +109,23 @@ func (r *Resolver) Resolve(node any) {
 
 	case *Ast.AnonymousFuncion:
 		enclosingFunction := r.currentFunction
-		r.currentFunction = FUNCTION
+		r.currentFunction = functionType(n.IsGenerator, n.IsAsync)
 		r.beginScope()
-		for _, arg := range n.Params {
-			r.declare(arg)
-			r.define(arg)
-		}
+		r.resolveParams(n.Params, n.Defaults)
 		r.Resolve(n.Body)
 		r.endScope()
 		r.currentFunction = enclosingFunction
 		break
 
+	case *Ast.TestStmt:
+		if !r.scopes.IsEmpty() {
+			Error.ReportParseError(n.Keyword, "Tests must be declared at the top level.")
+		}
+		r.beginScope()
+		r.Resolve(n.Body)
+		r.endScope()
+		break
+
 	case *Ast.ExpressionStmt:
 		r.Resolve(n.Expression)
 		break
@@ -120,9 +146,74 @@ func (r *Resolver) Resolve(node any) {
 		if r.currentFunction == NONE {
 			Error.ReportParseError(n.Keyword, "Cannot return from top-level code.")
 		}
+		if r.currentFunction == GENERATOR && n.Value != nil {
+			Error.ReportParseError(n.Keyword, "Can't return a value from a generator.")
+		}
+		r.Resolve(n.Value)
+		break
+
+	case *Ast.YieldStmt:
+		if r.currentFunction == NONE {
+			Error.ReportParseError(n.Keyword, "Can't yield outside of a function.")
+		}
+		if r.currentFunction == ASYNC {
+			Error.ReportParseError(n.Keyword, "Can't yield in an async function.")
+		}
+		r.Resolve(n.Value)
+		break
+
+	case *Ast.GetExpr:
+		r.Resolve(n.Object)
+		break
+
+	case *Ast.SpawnExpr:
+		r.Resolve(n.Call)
+		break
+
+	case *Ast.AwaitExpr:
+		// at the top level await runs the event loop until the future settles
+		if r.currentFunction != ASYNC && r.currentFunction != NONE {
+			Error.ReportParseError(n.Keyword, "Can't await outside of an async function.")
+		}
 		r.Resolve(n.Value)
 		break
 
+	case *Ast.MatchStmt:
+		r.Resolve(n.Value)
+		for _, c := range n.Cases {
+			r.resolveMatchCase(c, c.Body)
+		}
+		r.Resolve(n.Default)
+		break
+
+	case *Ast.MatchExpr:
+		r.Resolve(n.Value)
+		for _, c := range n.Cases {
+			r.resolveMatchCase(c, c.Result)
+		}
+		r.Resolve(n.Default)
+		break
+
+	case *Ast.SelectStmt:
+		for _, c := range n.Cases {
+			r.Resolve(c.Channel)
+			r.Resolve(c.Value)
+		}
+		for _, c := range n.Cases {
+			// the value a recv case receives is in a scope around its body
+			if c.Name == nil {
+				r.Resolve(c.Body)
+				continue
+			}
+			r.beginScope()
+			r.declare(c.Name)
+			r.define(c.Name)
+			r.Resolve(c.Body)
+			r.endScope()
+		}
+		r.Resolve(n.Default)
+		break
+
 	case *Ast.WhileStmt:
 		r.Resolve(n.Condition)
 		r.Resolve(n.Body)
@@ -153,6 +244,18 @@ func (r *Resolver) Resolve(node any) {
 		r.Resolve(n.Right)
 		break
 
+	case *Ast.InterpolationExpr:
+		for _, part := range n.Parts {
+			r.Resolve(part)
+		}
+		break
+
+	case *Ast.ConditionalExpr:
+		r.Resolve(n.Condition)
+		r.Resolve(n.Then)
+		r.Resolve(n.Else)
+		break
+
 	case *Ast.UnaryExpr:
 		r.Resolve(n.Right)
 		break
@@ -160,14 +263,48 @@ func (r *Resolver) Resolve(node any) {
 	}
 }
 
+// A binding pattern declares its name in a scope around the guard and the
+// body of its case. Which pattern matched is only known at runtime, so a
+// case with several patterns can't have one
+func (r *Resolver) resolveMatchCase(c *Ast.MatchCase, body any) {
+	var binding *Tokens.Token
+	for _, pattern := range c.Patterns {
+		if pattern.Kind != Ast.BindingPattern {
+			continue
+		}
+		if len(c.Patterns) > 1 {
+			Error.ReportParseError(pattern.Token, "Can't bind a name in a case with several patterns.")
+		}
+		binding = pattern.Token
+	}
+	if binding == nil {
+		r.Resolve(c.Guard)
+		r.Resolve(body)
+		return
+	}
+	r.beginScope()
+	r.declare(binding)
+	r.define(binding)
+	r.Resolve(c.Guard)
+	r.Resolve(body)
+	r.endScope()
+}
+
+func functionType(isGenerator bool, isAsync bool) int {
+	if isAsync {
+		return ASYNC
+	}
+	if isGenerator {
+		return GENERATOR
+	}
+	return FUNCTION
+}
+
 func (r *Resolver) resolveFunction(stmt *Ast.NamedFunction) {
 	enclosingFunction := r.currentFunction
-	r.currentFunction = FUNCTION
+	r.currentFunction = functionType(stmt.IsGenerator, stmt.IsAsync)
 	r.beginScope()
-	for _, arg := range stmt.Params {
-		r.declare(arg)
-		r.define(arg)
-	}
+	r.resolveParams(stmt.Params, stmt.Defaults)
 	r.Resolve(stmt.Body)
 	r.endScope()
 	r.currentFunction = enclosingFunction
@@ -176,14 +313,29 @@ func (r *Resolver) resolveFunction(stmt *Ast.NamedFunction) {
 func (r *Resolver) resolveLocal(expr Ast.Expr, name *Tokens.Token) {
 	for i := r.scopes.Size() - 1; i >= 0; i-- {
 		scope, _ := r.scopes.Get(i)
-		if _, ok := scope[name.Lexeme]; ok {
-			scope[name.Lexeme] = USED
+		if v, ok := scope[name.Lexeme]; ok {
+			v.status = USED
 			r.interpreter.Resolve(expr, r.scopes.Size()-1-i)
 			return
 		}
 	}
 }
 
+// Constants at the top level are global, assigning them is left for the
+// interpreter to catch
+func (r *Resolver) resolveAssign(expr Ast.Expr, name *Tokens.Token) {
+	for i := r.scopes.Size() - 1; i >= 0; i-- {
+		scope, _ := r.scopes.Get(i)
+		if v, ok := scope[name.Lexeme]; ok {
+			if v.constant {
+				Error.ReportParseError(name, fmt.Sprintf("Can't assign to constant '%s'.", name.Lexeme))
+			}
+			break
+		}
+	}
+	r.resolveLocal(expr, name)
+}
+
 func (r *Resolver) declare(name *Tokens.Token) {
 	scope, err := r.scopes.Peek()
 	if _, ok := scope[name.Lexeme]; ok {
@@ -192,7 +344,7 @@ func (r *Resolver) declare(name *Tokens.Token) {
 	if err != nil {
 		return
 	}
-	scope[name.Lexeme] = DECLARED
+	scope[name.Lexeme] = &variable{name: name, status: DECLARED}
 }
 
 func (r *Resolver) define(name *Tokens.Token) {
@@ -200,19 +352,48 @@ func (r *Resolver) define(name *Tokens.Token) {
 	if err != nil {
 		return
 	}
-	scope[name.Lexeme] = DEFINED
+	if v, ok := scope[name.Lexeme]; ok {
+		v.status = DEFINED
+	}
+}
+
+// Defaults are evaluated in the scope of the function once the params
+// before them are bound, but outside of a generator or async body
+func (r *Resolver) resolveParams(params []*Tokens.Token, defaults []Ast.Expr) {
+	for index, param := range params {
+		r.declare(param)
+		if index < len(defaults) && defaults[index] != nil {
+			enclosingFunction := r.currentFunction
+			r.currentFunction = FUNCTION
+			r.Resolve(defaults[index])
+			r.currentFunction = enclosingFunction
+		}
+		r.define(param)
+		// unused params are left to the linter, callbacks often ignore some of theirs
+		scope, _ := r.scopes.Peek()
+		scope[param.Lexeme].param = true
+	}
 }
 
 func (r *Resolver) beginScope() {
-	r.scopes.Push(map[string]int{})
+	r.scopes.Push(map[string]*variable{})
 }
 
 func (r *Resolver) endScope() {
 	scope, _ := r.scopes.Peek()
-	for varName, status := range scope {
-		if !isUsed(status) {
-			Error.ReportResolverError(fmt.Sprintf("Variable '%s' was declared but never used", varName))
+	unused := []*variable{}
+	for _, v := range scope {
+		if !isUsed(v.status) && !v.param {
+			unused = append(unused, v)
 		}
 	}
+	// map order is random, report in source order
+	sort.Slice(unused, func(a, b int) bool {
+		x, y := unused[a].name, unused[b].name
+		return x.Line < y.Line || (x.Line == y.Line && x.Column < y.Column)
+	})
+	for _, v := range unused {
+		Error.ReportWarning(v.name, fmt.Sprintf("Variable '%s' was declared but never used", v.name.Lexeme))
+	}
 	r.scopes.Pop()
 }
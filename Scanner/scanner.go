@@ -3,30 +3,51 @@ package Scanner
 import (
 	"fmt"
 	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/AnshVM/golox/Error"
 	"github.com/AnshVM/golox/Tokens"
 )
 
+// Positions and columns count runes, not bytes
 type Scanner struct {
-	source  string
+	source  []rune
 	tokens  []*Tokens.Token
 	start   uint
 	current uint
 	line    uint
+	// offset of the first character of the current line
+	lineStart uint
+	// column of the token being scanned
+	column uint
+	// one entry per ${ still open, counting the braces opened inside it
+	interpolations []int
+	comments       []*Tokens.Token
 }
 
 func NewScanner(source string) Scanner {
-	return Scanner{source: source, tokens: []*Tokens.Token{}}
+	return Scanner{source: []rune(source), tokens: []*Tokens.Token{}}
+}
+
+// The `//` comments found by ScanTokens, for tools that read them
+func (scanner *Scanner) Comments() []*Tokens.Token {
+	return scanner.comments
 }
 
 func (scanner *Scanner) ScanTokens() []*Tokens.Token {
 	for !scanner.isAtEnd() {
 		scanner.start = scanner.current
+		scanner.column = scanner.start - scanner.lineStart
 		scanner.scanToken()
 	}
+	if len(scanner.interpolations) > 0 {
+		Error.ReportScanError(scanner.line, "Unterminated string interpolation")
+	}
 	//start = current for case where the input ends with comment
 	scanner.start = scanner.current
+	scanner.column = scanner.start - scanner.lineStart
 	scanner.addToken(Tokens.EOF, nil)
 	return scanner.tokens
 }
@@ -45,28 +66,57 @@ func (scanner *Scanner) scanToken() {
 		scanner.addToken(Tokens.RIGHT_PAREN, nil)
 		break
 	case '{':
+		if open := len(scanner.interpolations); open > 0 {
+			scanner.interpolations[open-1]++
+		}
 		scanner.addToken(Tokens.LEFT_BRACE, nil)
 		break
 	case '}':
+		if open := len(scanner.interpolations); open > 0 {
+			if scanner.interpolations[open-1] == 0 {
+				// closes the ${, the rest of the string follows
+				scanner.interpolations = scanner.interpolations[:open-1]
+				scanner.string()
+				break
+			}
+			scanner.interpolations[open-1]--
+		}
 		scanner.addToken(Tokens.RIGHT_BRACE, nil)
 		break
 	case ',':
 		scanner.addToken(Tokens.COMMA, nil)
 		break
 	case '.':
+		if scanner.peek() == '.' && scanner.peekNext() == '.' {
+			scanner.advance()
+			scanner.advance()
+			scanner.addToken(Tokens.ELLIPSIS, nil)
+			break
+		}
 		scanner.addToken(Tokens.DOT, nil)
 		break
 	case '-':
-		scanner.addToken(Tokens.MINUS, nil)
+		if scanner.match('-') {
+			scanner.addToken(Tokens.MINUS_MINUS, nil)
+			break
+		}
+		scanner.matchAddToken('=', Tokens.MINUS_EQUAL, Tokens.MINUS)
 		break
 	case '+':
-		scanner.addToken(Tokens.PLUS, nil)
+		if scanner.match('+') {
+			scanner.addToken(Tokens.PLUS_PLUS, nil)
+			break
+		}
+		scanner.matchAddToken('=', Tokens.PLUS_EQUAL, Tokens.PLUS)
 		break
 	case ';':
 		scanner.addToken(Tokens.SEMICOLON, nil)
 		break
 	case '*':
-		scanner.addToken(Tokens.STAR, nil)
+		scanner.matchAddToken('=', Tokens.STAR_EQUAL, Tokens.STAR)
+		break
+	case '%':
+		scanner.matchAddToken('=', Tokens.PERCENT_EQUAL, Tokens.PERCENT)
 		break
 	case '?':
 		scanner.addToken(Tokens.QUESTION_MARK, nil)
@@ -78,6 +128,10 @@ func (scanner *Scanner) scanToken() {
 		scanner.matchAddToken('=', Tokens.BANG_EQUAL, Tokens.BANG)
 		break
 	case '=':
+		if scanner.match('>') {
+			scanner.addToken(Tokens.ARROW, nil)
+			break
+		}
 		scanner.matchAddToken('=', Tokens.EQUAL_EQUAL, Tokens.EQUAL)
 		break
 	case '>':
@@ -91,6 +145,12 @@ func (scanner *Scanner) scanToken() {
 			for scanner.peek() != '\n' && !scanner.isAtEnd() {
 				scanner.advance()
 			}
+			scanner.comments = append(scanner.comments, &Tokens.Token{
+				Type:   Tokens.COMMENT,
+				Lexeme: string(scanner.source[scanner.start:scanner.current]),
+				Line:   scanner.line,
+				Column: scanner.column,
+			})
 		} else if scanner.match('*') {
 			for !scanner.isAtEnd() {
 				if scanner.peek() == '*' && scanner.peekNext() == '/' {
@@ -98,10 +158,12 @@ func (scanner *Scanner) scanToken() {
 					scanner.advance()
 					break
 				}
-				scanner.advance()
+				if scanner.advance() == '\n' {
+					scanner.newLine()
+				}
 			}
 		} else {
-			scanner.addToken(Tokens.SLASH, nil)
+			scanner.matchAddToken('=', Tokens.SLASH_EQUAL, Tokens.SLASH)
 		}
 		break
 	case '"':
@@ -114,7 +176,7 @@ func (scanner *Scanner) scanToken() {
 		break
 
 	case '\n':
-		scanner.line++
+		scanner.newLine()
 		break
 
 	default:
@@ -134,7 +196,7 @@ func (scanner *Scanner) identifier() {
 	for isAlphaNumeirc(scanner.peek()) {
 		scanner.advance()
 	}
-	text := scanner.source[scanner.start:scanner.current]
+	text := string(scanner.source[scanner.start:scanner.current])
 	if Tokens.Keywords[text] == "" {
 		scanner.addToken(Tokens.IDENTIFIER, nil)
 	} else {
@@ -143,11 +205,12 @@ func (scanner *Scanner) identifier() {
 
 }
 
-func isAlpha(c byte) bool {
-	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_'
+// any letter can start an identifier, not only ASCII ones
+func isAlpha(c rune) bool {
+	return unicode.IsLetter(c) || c == '_'
 }
 
-func isAlphaNumeirc(c byte) bool {
+func isAlphaNumeirc(c rune) bool {
 	return isAlpha(c) || isDigit(c)
 }
 
@@ -161,38 +224,96 @@ func (scanner *Scanner) number() {
 			scanner.advance()
 		}
 	}
-	value, _ := strconv.ParseFloat(scanner.source[scanner.start:scanner.current], 32)
+	value, _ := strconv.ParseFloat(string(scanner.source[scanner.start:scanner.current]), 32)
 	scanner.addToken(Tokens.NUMBER, float32(value))
 }
 
-func (scanner *Scanner) peekNext() byte {
-	if scanner.current == uint(len(scanner.source)-1) {
-		return byte(0)
+func (scanner *Scanner) peekNext() rune {
+	if scanner.current+1 >= uint(len(scanner.source)) {
+		return 0
 	}
 	return scanner.source[scanner.current+1]
 }
 
-func isDigit(c byte) bool {
+func isDigit(c rune) bool {
 	return c >= '0' && c <= '9'
 }
 
+// Scans the rest of a string after its opening '"', or after the '}' that
+// closes an interpolation. A string with interpolations becomes an
+// INTERPOLATION token for every part that ends with ${, followed by the
+// tokens of the expression, and a STRING token for the last part:
+// "a ${x} b" is INTERPOLATION("a ") IDENTIFIER(x) STRING(" b")
 func (scanner *Scanner) string() {
+	var value strings.Builder
 	for scanner.peek() != '"' && !scanner.isAtEnd() {
-		if scanner.peek() == '\n' {
-			scanner.line++
+		if scanner.peek() == '$' && scanner.peekNext() == '{' {
+			scanner.advance()
+			scanner.advance()
+			scanner.addToken(Tokens.INTERPOLATION, value.String())
+			scanner.interpolations = append(scanner.interpolations, 0)
+			return
+		}
+		c := scanner.advance()
+		switch c {
+		case '\\':
+			scanner.escape(&value)
+		case '\n':
+			scanner.newLine()
+			value.WriteRune(c)
+		default:
+			value.WriteRune(c)
 		}
-		scanner.advance()
 	}
 	if scanner.isAtEnd() {
 		Error.ReportScanError(scanner.line, "Unterminated string")
 		return
 	}
 	scanner.advance()
-	value := scanner.source[scanner.start+1 : scanner.current-1]
-	scanner.addToken(Tokens.STRING, value)
+	scanner.addToken(Tokens.STRING, value.String())
+}
+
+var escapes = map[rune]rune{
+	'n':  '\n',
+	't':  '\t',
+	'r':  '\r',
+	'0':  0,
+	'"':  '"',
+	'\\': '\\',
+	'$':  '$',
+}
+
+// Writes the character escaped by the backslash just scanned, \u{...}
+// takes the hex code of any unicode character
+func (scanner *Scanner) escape(value *strings.Builder) {
+	if scanner.isAtEnd() {
+		return
+	}
+	c := scanner.advance()
+	if escaped, ok := escapes[c]; ok {
+		value.WriteRune(escaped)
+		return
+	}
+	if c == 'u' && scanner.match('{') {
+		digits := ""
+		for scanner.peek() != '}' && scanner.peek() != '"' && !scanner.isAtEnd() {
+			digits += string(scanner.advance())
+		}
+		code, err := strconv.ParseUint(digits, 16, 32)
+		if !scanner.match('}') || err != nil || len(digits) > 6 || !utf8.ValidRune(rune(code)) {
+			Error.ReportScanError(scanner.line, fmt.Sprintf("Invalid unicode escape '\\u{%s}'", digits))
+			return
+		}
+		value.WriteRune(rune(code))
+		return
+	}
+	if c == '\n' {
+		scanner.newLine()
+	}
+	Error.ReportScanError(scanner.line, fmt.Sprintf("Invalid escape sequence '\\%c'", c))
 }
 
-func (scanner *Scanner) peek() byte {
+func (scanner *Scanner) peek() rune {
 	if scanner.isAtEnd() {
 		return 0
 	}
@@ -200,7 +321,7 @@ func (scanner *Scanner) peek() byte {
 }
 
 // because go does not support ternary
-func (scanner *Scanner) matchAddToken(match_char byte, token_matched string, token_unmatched string) {
+func (scanner *Scanner) matchAddToken(match_char rune, token_matched string, token_unmatched string) {
 	if scanner.match(match_char) {
 		scanner.addToken(token_matched, nil)
 	} else {
@@ -208,11 +329,11 @@ func (scanner *Scanner) matchAddToken(match_char byte, token_matched string, tok
 	}
 }
 
-func (scanner *Scanner) match(c byte) bool {
+func (scanner *Scanner) match(c rune) bool {
 	if scanner.isAtEnd() {
 		return false
 	}
-	if c != byte(scanner.source[scanner.current]) {
+	if c != scanner.source[scanner.current] {
 		return false
 	}
 	scanner.current++
@@ -220,14 +341,25 @@ func (scanner *Scanner) match(c byte) bool {
 }
 
 func (scanner *Scanner) addToken(tokenType string, literal any) {
-	lexeme := scanner.source[scanner.start:scanner.current]
+	lexeme := string(scanner.source[scanner.start:scanner.current])
 	scanner.tokens = append(
 		scanner.tokens,
-		&Tokens.Token{Type: tokenType, Lexeme: lexeme, Literal: literal, Line: scanner.line},
+		&Tokens.Token{
+			Type:    tokenType,
+			Lexeme:  lexeme,
+			Literal: literal,
+			Line:    scanner.line,
+			Column:  scanner.column,
+		},
 	)
 }
 
-func (scanner *Scanner) advance() byte {
+func (scanner *Scanner) newLine() {
+	scanner.line++
+	scanner.lineStart = scanner.current
+}
+
+func (scanner *Scanner) advance() rune {
 	scanner.current++
 	return scanner.source[scanner.current-1]
 }
@@ -0,0 +1,496 @@
+package Serializer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/AnshVM/golox/Ast"
+	"github.com/AnshVM/golox/Error"
+	"github.com/AnshVM/golox/Scanner"
+	"github.com/AnshVM/golox/Tokens"
+)
+
+type fields = map[string]json.RawMessage
+
+// Decodes a document written by Marshal, or by any tool following the schema
+func Unmarshal(data []byte) ([]Ast.Stmt, error) {
+	var doc struct {
+		Version    *int              `json:"version"`
+		Statements []json.RawMessage `json:"statements"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Version == nil {
+		return nil, fmt.Errorf("missing schema version")
+	}
+	if *doc.Version != Version {
+		return nil, fmt.Errorf("unsupported schema version %d, expected %d", *doc.Version, Version)
+	}
+	d := &decoder{}
+	stmts := []Ast.Stmt{}
+	for _, raw := range doc.Statements {
+		stmts = append(stmts, d.stmt(raw))
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	return stmts, nil
+}
+
+// keeps the first error so decoding functions can be chained without checks
+type decoder struct {
+	err error
+}
+
+func (d *decoder) fail(format string, args ...any) {
+	if d.err == nil {
+		d.err = fmt.Errorf(format, args...)
+	}
+}
+
+func (d *decoder) object(raw json.RawMessage) (fields, string) {
+	var f fields
+	if err := json.Unmarshal(raw, &f); err != nil || f == nil {
+		d.fail("expected an object, got %s", string(raw))
+		return fields{}, ""
+	}
+	var kind string
+	json.Unmarshal(f["kind"], &kind)
+	return f, kind
+}
+
+func (d *decoder) stmt(raw json.RawMessage) Ast.Stmt {
+	f, kind := d.object(raw)
+	if d.err != nil {
+		return nil
+	}
+	switch kind {
+	case "Expression":
+		return &Ast.ExpressionStmt{Expression: d.expr(f, kind, "expression")}
+	case "Print":
+		return &Ast.PrintStmt{Keyword: d.optionalToken(f, kind, "keyword"), Expression: d.expr(f, kind, "expression")}
+	case "Var":
+		stmt := &Ast.VarStmt{
+			Name:        d.token(f, kind, "name"),
+			Type:        d.optionalToken(f, kind, "type"),
+			Initializer: d.optionalExpr(f, "initializer"),
+			IsConst:     d.flag(f, kind, "const"),
+		}
+		if stmt.IsConst && stmt.Initializer == nil {
+			d.fail("%s: a const needs an initializer", kind)
+		}
+		return stmt
+	case "Block":
+		return &Ast.BlockStmt{Brace: d.optionalToken(f, kind, "brace"), Statements: d.stmts(f, kind, "statements")}
+	case "If":
+		return &Ast.IfStmt{
+			Keyword:    d.optionalToken(f, kind, "keyword"),
+			Condition:  d.expr(f, kind, "condition"),
+			ThenBranch: d.required(f, kind, "then", d.stmt),
+			ElseBranch: d.optionalStmt(f, "else"),
+		}
+	case "While":
+		return &Ast.WhileStmt{
+			Keyword:   d.optionalToken(f, kind, "keyword"),
+			Condition: d.optionalExpr(f, "condition"),
+			Body:      d.required(f, kind, "body", d.stmt),
+		}
+	case "Function":
+		params := d.tokens(f, kind, "params")
+		stmt := &Ast.NamedFunction{
+			Name:        d.token(f, kind, "name"),
+			Params:      params,
+			ParamTypes:  d.paramTypes(f, kind, len(params)),
+			Defaults:    d.defaults(f, kind, len(params)),
+			ReturnType:  d.optionalToken(f, kind, "returnType"),
+			Body:        d.stmts(f, kind, "body"),
+			IsGenerator: d.flag(f, kind, "generator"),
+			IsAsync:     d.flag(f, kind, "async"),
+			IsVariadic:  d.flag(f, kind, "variadic"),
+		}
+		d.checkParams(kind, stmt.Defaults, stmt.IsVariadic)
+		return stmt
+	case "Test":
+		name := d.token(f, kind, "name")
+		if name != nil {
+			name.Literal = d.stringLiteral(kind, "name", name.Lexeme)
+		}
+		return &Ast.TestStmt{Keyword: d.token(f, kind, "keyword"), Name: name, Body: d.stmts(f, kind, "body")}
+	case "Yield":
+		return &Ast.YieldStmt{Keyword: d.token(f, kind, "keyword"), Value: d.optionalExpr(f, "value")}
+	case "Return":
+		return &Ast.Return{Keyword: d.token(f, kind, "keyword"), Value: d.optionalExpr(f, "value")}
+	case "Match":
+		return &Ast.MatchStmt{
+			Keyword: d.token(f, kind, "keyword"),
+			Value:   d.expr(f, kind, "value"),
+			Cases:   d.matchCases(f, kind, false),
+			Default: d.optionalStmt(f, "default"),
+		}
+	case "Select":
+		stmt := &Ast.SelectStmt{Keyword: d.token(f, kind, "keyword"), Cases: []*Ast.SelectCase{}, Default: d.optionalStmt(f, "default")}
+		for _, raw := range d.list(f, kind, "cases") {
+			stmt.Cases = append(stmt.Cases, d.selectCase(raw))
+		}
+		return stmt
+	}
+	d.fail("unknown statement kind %q", kind)
+	return nil
+}
+
+func (d *decoder) exprValue(raw json.RawMessage) Ast.Expr {
+	f, kind := d.object(raw)
+	if d.err != nil {
+		return nil
+	}
+	switch kind {
+	case "Conditional":
+		return &Ast.ConditionalExpr{Condition: d.expr(f, kind, "condition"), Then: d.expr(f, kind, "then"), Else: d.expr(f, kind, "else")}
+	case "Binary":
+		return &Ast.BinaryExpr{Left: d.expr(f, kind, "left"), Operator: d.token(f, kind, "operator"), Right: d.expr(f, kind, "right")}
+	case "Logical":
+		return &Ast.LogicalExpr{Left: d.expr(f, kind, "left"), Operator: d.token(f, kind, "operator"), Right: d.expr(f, kind, "right")}
+	case "Grouping":
+		return &Ast.GroupingExpr{Expression: d.expr(f, kind, "expression")}
+	case "Literal":
+		return &Ast.LiteralExpr{Value: d.literal(f, kind), Token: d.optionalToken(f, kind, "token")}
+	case "Unary":
+		return &Ast.UnaryExpr{Operator: d.token(f, kind, "operator"), Right: d.expr(f, kind, "right")}
+	case "Variable":
+		return &Ast.VariableExpr{Name: d.token(f, kind, "name")}
+	case "Assign":
+		return &Ast.AssignExpr{Name: d.token(f, kind, "name"), Value: d.expr(f, kind, "value")}
+	case "CompoundAssign":
+		return &Ast.CompoundAssignExpr{Name: d.token(f, kind, "name"), Operator: d.token(f, kind, "operator"), Value: d.expr(f, kind, "value")}
+	case "Increment":
+		return &Ast.IncrementExpr{Name: d.token(f, kind, "name"), Operator: d.token(f, kind, "operator"), IsPrefix: d.flag(f, kind, "prefix")}
+	case "Get":
+		return &Ast.GetExpr{Object: d.expr(f, kind, "object"), Name: d.token(f, kind, "name")}
+	case "MatchExpression":
+		return &Ast.MatchExpr{
+			Keyword: d.token(f, kind, "keyword"),
+			Value:   d.expr(f, kind, "value"),
+			Cases:   d.matchCases(f, kind, true),
+			Default: d.optionalExpr(f, "default"),
+		}
+	case "Await":
+		return &Ast.AwaitExpr{Keyword: d.token(f, kind, "keyword"), Value: d.expr(f, kind, "value")}
+	case "Spawn":
+		keyword := d.token(f, kind, "keyword")
+		call, ok := d.expr(f, kind, "call").(*Ast.Call)
+		if !ok {
+			d.fail("%s: field %q must be a Call", kind, "call")
+			return nil
+		}
+		return &Ast.SpawnExpr{Keyword: keyword, Call: call}
+	case "Call":
+		args := d.exprs(f, kind, "arguments")
+		return &Ast.Call{
+			Callee:    d.expr(f, kind, "callee"),
+			Paren:     d.token(f, kind, "paren"),
+			Arguments: args,
+			Names:     d.optionalTokens(f, kind, "names", "argument", len(args)),
+		}
+	case "Interpolation":
+		return &Ast.InterpolationExpr{Parts: d.exprs(f, kind, "parts")}
+	case "Lambda":
+		params := d.tokens(f, kind, "params")
+		expr := &Ast.AnonymousFuncion{
+			Params:      params,
+			ParamTypes:  d.paramTypes(f, kind, len(params)),
+			Defaults:    d.defaults(f, kind, len(params)),
+			ReturnType:  d.optionalToken(f, kind, "returnType"),
+			Body:        d.stmts(f, kind, "body"),
+			IsGenerator: d.flag(f, kind, "generator"),
+			IsAsync:     d.flag(f, kind, "async"),
+			IsVariadic:  d.flag(f, kind, "variadic"),
+		}
+		d.checkParams(kind, expr.Defaults, expr.IsVariadic)
+		return expr
+	}
+	d.fail("unknown expression kind %q", kind)
+	return nil
+}
+
+// cases of a match are objects without a kind, with a result instead of a
+// body in a match expression
+func (d *decoder) matchCases(f fields, kind string, isExpr bool) []*Ast.MatchCase {
+	cases := []*Ast.MatchCase{}
+	for _, raw := range d.list(f, kind, "cases") {
+		const kind = "MatchCase"
+		f, _ := d.object(raw)
+		c := &Ast.MatchCase{Keyword: d.token(f, kind, "keyword"), Patterns: []*Ast.Pattern{}, Guard: d.optionalExpr(f, "guard")}
+		for _, raw := range d.list(f, kind, "patterns") {
+			c.Patterns = append(c.Patterns, d.pattern(raw))
+		}
+		if isExpr {
+			c.Result = d.expr(f, kind, "result")
+		} else {
+			c.Body = d.required(f, kind, "body", d.stmt)
+		}
+		cases = append(cases, c)
+	}
+	return cases
+}
+
+func (d *decoder) pattern(raw json.RawMessage) *Ast.Pattern {
+	const kind = "Pattern"
+	f, name := d.object(raw)
+	pattern := &Ast.Pattern{Token: d.token(f, kind, "token")}
+	switch name {
+	case "literal":
+		pattern.Kind = Ast.LiteralPattern
+		pattern.Value = d.literal(f, kind)
+	case "binding":
+		pattern.Kind = Ast.BindingPattern
+	case "wildcard":
+		pattern.Kind = Ast.WildcardPattern
+	default:
+		d.fail("unknown pattern kind %q", name)
+	}
+	return pattern
+}
+
+// cases of a select are objects without a kind
+func (d *decoder) selectCase(raw json.RawMessage) *Ast.SelectCase {
+	const kind = "SelectCase"
+	f, _ := d.object(raw)
+	c := &Ast.SelectCase{
+		Keyword: d.token(f, kind, "keyword"),
+		Name:    d.optionalToken(f, kind, "name"),
+		Op:      d.token(f, kind, "op"),
+		Channel: d.expr(f, kind, "channel"),
+		Value:   d.optionalExpr(f, "value"),
+		Body:    d.required(f, kind, "body", d.stmt),
+	}
+	if c.Op != nil && c.Op.Lexeme != "recv" && c.Op.Lexeme != "send" {
+		d.fail("%s: op must be recv or send, got %q", kind, c.Op.Lexeme)
+	}
+	return c
+}
+
+func (d *decoder) field(f fields, kind string, name string) json.RawMessage {
+	raw, ok := f[name]
+	if !ok || string(raw) == "null" {
+		d.fail("%s: missing field %q", kind, name)
+		return nil
+	}
+	return raw
+}
+
+func (d *decoder) required(f fields, kind string, name string, decode func(json.RawMessage) Ast.Stmt) Ast.Stmt {
+	if raw := d.field(f, kind, name); raw != nil {
+		return decode(raw)
+	}
+	return nil
+}
+
+func (d *decoder) expr(f fields, kind string, name string) Ast.Expr {
+	if raw := d.field(f, kind, name); raw != nil {
+		return d.exprValue(raw)
+	}
+	return nil
+}
+
+func (d *decoder) optionalExpr(f fields, name string) Ast.Expr {
+	if raw, ok := f[name]; ok && string(raw) != "null" {
+		return d.exprValue(raw)
+	}
+	return nil
+}
+
+func (d *decoder) optionalStmt(f fields, name string) Ast.Stmt {
+	if raw, ok := f[name]; ok && string(raw) != "null" {
+		return d.stmt(raw)
+	}
+	return nil
+}
+
+func (d *decoder) list(f fields, kind string, name string) []json.RawMessage {
+	var list []json.RawMessage
+	if raw := d.field(f, kind, name); raw != nil {
+		if err := json.Unmarshal(raw, &list); err != nil {
+			d.fail("%s: field %q must be a list", kind, name)
+		}
+	}
+	return list
+}
+
+func (d *decoder) stmts(f fields, kind string, name string) []Ast.Stmt {
+	stmts := []Ast.Stmt{}
+	for _, raw := range d.list(f, kind, name) {
+		stmts = append(stmts, d.stmt(raw))
+	}
+	return stmts
+}
+
+func (d *decoder) exprs(f fields, kind string, name string) []Ast.Expr {
+	exprs := []Ast.Expr{}
+	for _, raw := range d.list(f, kind, name) {
+		exprs = append(exprs, d.exprValue(raw))
+	}
+	return exprs
+}
+
+func (d *decoder) token(f fields, kind string, name string) *Tokens.Token {
+	raw := d.field(f, kind, name)
+	if raw == nil {
+		return nil
+	}
+	return d.tokenValue(kind, name, raw)
+}
+
+func (d *decoder) tokenValue(kind string, name string, raw json.RawMessage) *Tokens.Token {
+	var t struct {
+		Type   string `json:"type"`
+		Lexeme string `json:"lexeme"`
+		Line   uint   `json:"line"`
+		Column uint   `json:"column"`
+	}
+	if err := json.Unmarshal(raw, &t); err != nil || t.Type == "" {
+		d.fail("%s: field %q must be a token with a type", kind, name)
+		return nil
+	}
+	token := &Tokens.Token{Type: t.Type, Lexeme: t.Lexeme}
+	if t.Line > 0 {
+		token.Line = t.Line - 1
+	}
+	if t.Column > 0 {
+		token.Column = t.Column - 1
+	}
+	return token
+}
+
+func (d *decoder) optionalToken(f fields, kind string, name string) *Tokens.Token {
+	if raw, ok := f[name]; ok && string(raw) != "null" {
+		return d.tokenValue(kind, name, raw)
+	}
+	return nil
+}
+
+// one entry per param, null where a param has no annotation
+func (d *decoder) paramTypes(f fields, kind string, count int) []*Tokens.Token {
+	if types := d.optionalTokens(f, kind, "paramTypes", "param", count); types != nil {
+		return types
+	}
+	return make([]*Tokens.Token, count)
+}
+
+// A list with one token or null per param or argument, nil when the field is
+// left out
+func (d *decoder) optionalTokens(f fields, kind string, name string, per string, count int) []*Tokens.Token {
+	if raw, ok := f[name]; !ok || string(raw) == "null" {
+		return nil
+	}
+	tokens := make([]*Tokens.Token, count)
+	list := d.list(f, kind, name)
+	if len(list) != count {
+		d.fail("%s: %s must have one entry per %s", kind, name, per)
+		return tokens
+	}
+	for index, raw := range list {
+		if string(raw) != "null" {
+			tokens[index] = d.tokenValue(kind, name, raw)
+		}
+	}
+	return tokens
+}
+
+func (d *decoder) defaults(f fields, kind string, count int) []Ast.Expr {
+	defaults := make([]Ast.Expr, count)
+	if raw, ok := f["defaults"]; !ok || string(raw) == "null" {
+		return defaults
+	}
+	list := d.list(f, kind, "defaults")
+	if len(list) != count {
+		d.fail("%s: defaults must have one entry per param", kind)
+		return defaults
+	}
+	for index, raw := range list {
+		if string(raw) != "null" {
+			defaults[index] = d.exprValue(raw)
+		}
+	}
+	return defaults
+}
+
+// The rules the parser enforces on a parameter list, which the interpreter
+// relies on when it binds arguments
+func (d *decoder) checkParams(kind string, defaults []Ast.Expr, isVariadic bool) {
+	fixed := len(defaults)
+	if isVariadic {
+		if fixed == 0 {
+			d.fail("%s: a variadic function needs a rest param", kind)
+			return
+		}
+		fixed--
+		if defaults[fixed] != nil {
+			d.fail("%s: the rest param can't have a default", kind)
+		}
+	}
+	for index := 1; index < fixed; index++ {
+		if defaults[index] == nil && defaults[index-1] != nil {
+			d.fail("%s: a param without a default can't follow one with a default", kind)
+		}
+	}
+}
+
+// Tokens are written without their literal, a string token gets it back by
+// scanning its lexeme, so escapes mean what they mean in source code
+func (d *decoder) stringLiteral(kind string, name string, lexeme string) string {
+	output, hadError := Error.Output, Error.HadError
+	Error.Output, Error.HadError = ioutil.Discard, false
+	defer func() { Error.Output, Error.HadError = output, hadError }()
+	scanner := Scanner.NewScanner(lexeme)
+	tokens := scanner.ScanTokens()
+	if Error.HadError || len(tokens) != 2 || tokens[0].Type != Tokens.STRING {
+		d.fail("%s: field %q must be a string token, got %s", kind, name, lexeme)
+		return ""
+	}
+	return tokens[0].Literal.(string)
+}
+
+func (d *decoder) tokens(f fields, kind string, name string) []*Tokens.Token {
+	tokens := []*Tokens.Token{}
+	for _, raw := range d.list(f, kind, name) {
+		tokens = append(tokens, d.tokenValue(kind, name, raw))
+	}
+	return tokens
+}
+
+// an optional boolean, false when missing
+func (d *decoder) flag(f fields, kind string, name string) bool {
+	raw, ok := f[name]
+	if !ok || string(raw) == "null" {
+		return false
+	}
+	var value bool
+	if err := json.Unmarshal(raw, &value); err != nil {
+		d.fail("%s: field %q must be a boolean", kind, name)
+	}
+	return value
+}
+
+// numbers become float32 like the ones produced by the scanner
+func (d *decoder) literal(f fields, kind string) any {
+	raw, ok := f["value"]
+	if !ok {
+		d.fail("%s: missing field %q", kind, "value")
+		return nil
+	}
+	var value any
+	if err := json.Unmarshal(raw, &value); err != nil {
+		d.fail("%s: invalid value %s", kind, string(raw))
+		return nil
+	}
+	switch v := value.(type) {
+	case float64:
+		return float32(v)
+	case string, bool, nil:
+		return v
+	}
+	d.fail("%s: value must be a number, string, boolean or null", kind)
+	return nil
+}
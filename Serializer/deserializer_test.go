@@ -0,0 +1,113 @@
+package Serializer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/AnshVM/golox/Ast"
+	"github.com/AnshVM/golox/Parser"
+	"github.com/AnshVM/golox/Scanner"
+)
+
+// A document with one function declaration made of the given fields
+func function(fields string) string {
+	return `{"version": 1, "statements": [{"kind": "Function", "name": {"type": "IDENTIFIER", "lexeme": "f"}, "body": [], ` + fields + `}]}`
+}
+
+const (
+	paramA = `{"type": "IDENTIFIER", "lexeme": "a"}`
+	paramB = `{"type": "IDENTIFIER", "lexeme": "b"}`
+	one    = `{"kind": "Literal", "value": 1}`
+)
+
+func TestUnmarshalParams(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields string
+		err    string // empty when the function is valid
+	}{
+		{"plain", `"params": [` + paramA + `]`, ""},
+		{"rest", `"params": [` + paramA + `], "variadic": true`, ""},
+		{"default then rest", `"params": [` + paramA + `, ` + paramB + `], "defaults": [` + one + `, null], "variadic": true`, ""},
+		{"variadic without params", `"params": [], "variadic": true`, "Function: a variadic function needs a rest param"},
+		{"default on rest", `"params": [` + paramA + `], "defaults": [` + one + `], "variadic": true`, "Function: the rest param can't have a default"},
+		{"default before required", `"params": [` + paramA + `, ` + paramB + `], "defaults": [` + one + `, null]`, "Function: a param without a default can't follow one with a default"},
+		{"too few defaults", `"params": [` + paramA + `, ` + paramB + `], "defaults": [null]`, "Function: defaults must have one entry per param"},
+		{"too many param types", `"params": [` + paramA + `], "paramTypes": [null, null]`, "Function: paramTypes must have one entry per param"},
+	}
+	for _, test := range tests {
+		_, err := Unmarshal([]byte(function(test.fields)))
+		switch {
+		case test.err == "" && err != nil:
+			t.Errorf("%s: unexpected error %v", test.name, err)
+		case test.err != "" && err == nil:
+			t.Errorf("%s: decoded, want error %q", test.name, test.err)
+		case test.err != "" && err.Error() != test.err:
+			t.Errorf("%s: error %q, want %q", test.name, err, test.err)
+		}
+	}
+
+	// the same rules hold for lambdas
+	lambda := `{"version": 1, "statements": [{"kind": "Expression", "expression": {"kind": "Lambda", "params": [], "body": [], "variadic": true}}]}`
+	if _, err := Unmarshal([]byte(lambda)); err == nil || !strings.Contains(err.Error(), "needs a rest param") {
+		t.Errorf("variadic lambda without params: error %v", err)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	statement := func(s string) string { return `{"version": 1, "statements": [` + s + `]}` }
+	tests := []struct {
+		name     string
+		document string
+		err      string
+	}{
+		{"missing version", `{"statements": []}`, "missing schema version"},
+		{"newer version", `{"version": 2, "statements": []}`, "unsupported schema version 2, expected 1"},
+		{"older version", `{"version": 0, "statements": []}`, "unsupported schema version 0, expected 1"},
+		{"not an object", statement(`[]`), "expected an object, got []"},
+		{"unknown statement", statement(`{"kind": "Goto"}`), `unknown statement kind "Goto"`},
+		{"unknown expression", statement(`{"kind": "Expression", "expression": {"kind": "Pipe"}}`), `unknown expression kind "Pipe"`},
+		{"missing field", statement(`{"kind": "Print"}`), `Print: missing field "expression"`},
+		{"not a list", statement(`{"kind": "Block", "statements": {}}`), `Block: field "statements" must be a list`},
+		{"bad token", statement(`{"kind": "Var", "name": {"lexeme": "a"}}`), `Var: field "name" must be a token with a type`},
+		{"bad flag", statement(`{"kind": "Var", "name": ` + paramA + `, "initializer": ` + one + `, "const": "yes"}`), `Var: field "const" must be a boolean`},
+		{"bad test name", statement(`{"kind": "Test", "keyword": {"type": "IDENTIFIER", "lexeme": "test"}, "name": {"type": "STRING", "lexeme": "\"a\\q\""}, "body": []}`), `Test: field "name" must be a string token, got "a\q"`},
+		{"const without value", statement(`{"kind": "Var", "name": ` + paramA + `, "const": true}`), "Var: a const needs an initializer"},
+	}
+	for _, test := range tests {
+		stmts, err := Unmarshal([]byte(test.document))
+		if err == nil || err.Error() != test.err {
+			t.Errorf("%s: error %v, want %q", test.name, err, test.err)
+		}
+		if stmts != nil {
+			t.Errorf("%s: returned statements along with the error", test.name)
+		}
+	}
+	if _, err := Unmarshal([]byte(`{"version": 1`)); err == nil {
+		t.Error("truncated document: decoded without error")
+	}
+}
+
+// the names of tests come back with their escapes decoded like the scanner does
+func TestUnmarshalTestNames(t *testing.T) {
+	names := map[string]string{
+		`"plain"`:    "plain",
+		`"a\"b"`:     `a"b`,
+		`"x\ny"`:     "x\ny",
+		`"\u{e9}\$"`: "\u00e9$",
+	}
+	for lexeme, expected := range names {
+		scanner := Scanner.NewScanner("test " + lexeme + " {}")
+		encoded, err := Marshal(Parser.NewParser(scanner.ScanTokens()).Parse())
+		if err != nil {
+			t.Fatalf("%s: %v", lexeme, err)
+		}
+		decoded, err := Unmarshal(encoded)
+		if err != nil {
+			t.Fatalf("%s: %v", lexeme, err)
+		}
+		if name := decoded[0].(*Ast.TestStmt).Name.Literal; name != expected {
+			t.Errorf("%s: decoded name %q, want %q", lexeme, name, expected)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package Serializer
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/AnshVM/golox/Ast"
+	"github.com/AnshVM/golox/Tokens"
+)
+
+// Version of the schema described in docs/ast-schema.md. It is bumped for
+// changes that old readers can't ignore, adding optional fields or new kinds
+// doesn't change it
+const Version = 1
+
+type object = map[string]any
+
+// Encodes a program as a versioned JSON document
+func Marshal(stmts []Ast.Stmt) ([]byte, error) {
+	encoded := []any{}
+	for _, stmt := range stmts {
+		node, err := encode(stmt)
+		if err != nil {
+			return nil, err
+		}
+		encoded = append(encoded, node)
+	}
+	return json.MarshalIndent(object{"version": Version, "statements": encoded}, "", "  ")
+}
+
+func encode(node Ast.Node) (object, error) {
+	var err error
+	child := func(n Ast.Node) any {
+		if n == nil || err != nil {
+			return nil
+		}
+		var encoded object
+		encoded, err = encode(n)
+		return encoded
+	}
+	stmts := func(list []Ast.Stmt) []any {
+		encoded := []any{}
+		for _, stmt := range list {
+			encoded = append(encoded, child(stmt))
+		}
+		return encoded
+	}
+	// defaults have one entry per param, null when it has none, and are left
+	// out when no param has one
+	params := func(o object, defaults []Ast.Expr, isVariadic bool) {
+		for _, value := range defaults {
+			if value != nil {
+				encoded := []any{}
+				for _, value := range defaults {
+					encoded = append(encoded, child(value))
+				}
+				o["defaults"] = encoded
+				break
+			}
+		}
+		if isVariadic {
+			o["variadic"] = true
+		}
+	}
+	// the body of a case is a statement or, in a match expression, a result
+	cases := func(list []*Ast.MatchCase) []any {
+		encoded := []any{}
+		for _, c := range list {
+			patterns := []any{}
+			for _, pattern := range c.Patterns {
+				patterns = append(patterns, encodePattern(pattern))
+			}
+			encodedCase := object{"keyword": token(c.Keyword), "patterns": patterns}
+			if c.Guard != nil {
+				encodedCase["guard"] = child(c.Guard)
+			}
+			if c.Body != nil {
+				encodedCase["body"] = child(c.Body)
+			} else {
+				encodedCase["result"] = child(c.Result)
+			}
+			encoded = append(encoded, encodedCase)
+		}
+		return encoded
+	}
+
+	var o object
+	switch n := node.(type) {
+	case *Ast.ExpressionStmt:
+		o = object{"kind": "Expression", "expression": child(n.Expression)}
+	case *Ast.PrintStmt:
+		o = object{"kind": "Print", "expression": child(n.Expression)}
+		optionalToken(o, "keyword", n.Keyword)
+	case *Ast.VarStmt:
+		o = object{"kind": "Var", "name": token(n.Name)}
+		optionalToken(o, "type", n.Type)
+		if n.Initializer != nil {
+			o["initializer"] = child(n.Initializer)
+		}
+		if n.IsConst {
+			o["const"] = true
+		}
+	case *Ast.BlockStmt:
+		o = object{"kind": "Block", "statements": stmts(n.Statements)}
+		optionalToken(o, "brace", n.Brace)
+	case *Ast.IfStmt:
+		o = object{"kind": "If", "condition": child(n.Condition), "then": child(n.ThenBranch)}
+		optionalToken(o, "keyword", n.Keyword)
+		if n.ElseBranch != nil {
+			o["else"] = child(n.ElseBranch)
+		}
+	case *Ast.WhileStmt:
+		o = object{"kind": "While", "body": child(n.Body)}
+		if n.Condition != nil {
+			o["condition"] = child(n.Condition)
+		}
+		optionalToken(o, "keyword", n.Keyword)
+	case *Ast.NamedFunction:
+		o = object{"kind": "Function", "name": token(n.Name), "params": tokens(n.Params), "body": stmts(n.Body)}
+		annotations(o, n.ParamTypes, n.ReturnType)
+		params(o, n.Defaults, n.IsVariadic)
+		if n.IsGenerator {
+			o["generator"] = true
+		}
+		if n.IsAsync {
+			o["async"] = true
+		}
+	case *Ast.TestStmt:
+		o = object{"kind": "Test", "keyword": token(n.Keyword), "name": token(n.Name), "body": stmts(n.Body)}
+	case *Ast.Return:
+		o = object{"kind": "Return", "keyword": token(n.Keyword)}
+		if n.Value != nil {
+			o["value"] = child(n.Value)
+		}
+	case *Ast.YieldStmt:
+		o = object{"kind": "Yield", "keyword": token(n.Keyword)}
+		if n.Value != nil {
+			o["value"] = child(n.Value)
+		}
+	case *Ast.MatchStmt:
+		o = object{"kind": "Match", "keyword": token(n.Keyword), "value": child(n.Value), "cases": cases(n.Cases)}
+		if n.Default != nil {
+			o["default"] = child(n.Default)
+		}
+	case *Ast.SelectStmt:
+		cases := []any{}
+		for _, c := range n.Cases {
+			encoded := object{"keyword": token(c.Keyword), "op": token(c.Op), "channel": child(c.Channel), "body": child(c.Body)}
+			optionalToken(encoded, "name", c.Name)
+			if c.Value != nil {
+				encoded["value"] = child(c.Value)
+			}
+			cases = append(cases, encoded)
+		}
+		o = object{"kind": "Select", "keyword": token(n.Keyword), "cases": cases}
+		if n.Default != nil {
+			o["default"] = child(n.Default)
+		}
+	case *Ast.ConditionalExpr:
+		o = object{"kind": "Conditional", "condition": child(n.Condition), "then": child(n.Then), "else": child(n.Else)}
+	case *Ast.BinaryExpr:
+		o = object{"kind": "Binary", "left": child(n.Left), "operator": token(n.Operator), "right": child(n.Right)}
+	case *Ast.LogicalExpr:
+		o = object{"kind": "Logical", "left": child(n.Left), "operator": token(n.Operator), "right": child(n.Right)}
+	case *Ast.GroupingExpr:
+		o = object{"kind": "Grouping", "expression": child(n.Expression)}
+	case *Ast.LiteralExpr:
+		o = object{"kind": "Literal", "value": n.Value}
+		optionalToken(o, "token", n.Token)
+	case *Ast.UnaryExpr:
+		o = object{"kind": "Unary", "operator": token(n.Operator), "right": child(n.Right)}
+	case *Ast.VariableExpr:
+		o = object{"kind": "Variable", "name": token(n.Name)}
+	case *Ast.AssignExpr:
+		o = object{"kind": "Assign", "name": token(n.Name), "value": child(n.Value)}
+	case *Ast.CompoundAssignExpr:
+		o = object{"kind": "CompoundAssign", "name": token(n.Name), "operator": token(n.Operator), "value": child(n.Value)}
+	case *Ast.IncrementExpr:
+		o = object{"kind": "Increment", "name": token(n.Name), "operator": token(n.Operator)}
+		if n.IsPrefix {
+			o["prefix"] = true
+		}
+	case *Ast.GetExpr:
+		o = object{"kind": "Get", "object": child(n.Object), "name": token(n.Name)}
+	case *Ast.MatchExpr:
+		o = object{"kind": "MatchExpression", "keyword": token(n.Keyword), "value": child(n.Value), "cases": cases(n.Cases)}
+		if n.Default != nil {
+			o["default"] = child(n.Default)
+		}
+	case *Ast.AwaitExpr:
+		o = object{"kind": "Await", "keyword": token(n.Keyword), "value": child(n.Value)}
+	case *Ast.SpawnExpr:
+		o = object{"kind": "Spawn", "keyword": token(n.Keyword), "call": child(n.Call)}
+	case *Ast.Call:
+		args := []any{}
+		for _, arg := range n.Arguments {
+			args = append(args, child(arg))
+		}
+		o = object{"kind": "Call", "callee": child(n.Callee), "paren": token(n.Paren), "arguments": args}
+		if n.Names != nil {
+			o["names"] = tokens(n.Names)
+		}
+	case *Ast.InterpolationExpr:
+		parts := []any{}
+		for _, part := range n.Parts {
+			parts = append(parts, child(part))
+		}
+		o = object{"kind": "Interpolation", "parts": parts}
+	case *Ast.AnonymousFuncion:
+		o = object{"kind": "Lambda", "params": tokens(n.Params), "body": stmts(n.Body)}
+		annotations(o, n.ParamTypes, n.ReturnType)
+		params(o, n.Defaults, n.IsVariadic)
+		if n.IsGenerator {
+			o["generator"] = true
+		}
+		if n.IsAsync {
+			o["async"] = true
+		}
+	default:
+		return nil, fmt.Errorf("cannot serialize node of type %T", node)
+	}
+	return o, err
+}
+
+var patternKinds = map[Ast.PatternKind]string{
+	Ast.LiteralPattern:  "literal",
+	Ast.BindingPattern:  "binding",
+	Ast.WildcardPattern: "wildcard",
+}
+
+func encodePattern(pattern *Ast.Pattern) object {
+	o := object{"kind": patternKinds[pattern.Kind], "token": token(pattern.Token)}
+	if pattern.Kind == Ast.LiteralPattern {
+		o["value"] = pattern.Value
+	}
+	return o
+}
+
+// lines and columns are written starting from 1
+func token(t *Tokens.Token) object {
+	return object{"type": t.Type, "lexeme": t.Lexeme, "line": t.Line + 1, "column": t.Column + 1}
+}
+
+// nil tokens are written as null, to keep positions in lists like paramTypes
+func tokens(list []*Tokens.Token) []any {
+	encoded := []any{}
+	for _, t := range list {
+		if t == nil {
+			encoded = append(encoded, nil)
+		} else {
+			encoded = append(encoded, token(t))
+		}
+	}
+	return encoded
+}
+
+func annotations(o object, paramTypes []*Tokens.Token, returnType *Tokens.Token) {
+	for _, t := range paramTypes {
+		if t != nil {
+			o["paramTypes"] = tokens(paramTypes)
+			break
+		}
+	}
+	optionalToken(o, "returnType", returnType)
+}
+
+func optionalToken(o object, name string, t *Tokens.Token) {
+	if t != nil {
+		o[name] = token(t)
+	}
+}
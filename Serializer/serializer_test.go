@@ -0,0 +1,78 @@
+package Serializer
+
+import (
+	"bytes"
+	"io/fs"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/AnshVM/golox/Ast"
+	"github.com/AnshVM/golox/Error"
+	"github.com/AnshVM/golox/Parser"
+	"github.com/AnshVM/golox/Printer"
+	"github.com/AnshVM/golox/Scanner"
+)
+
+// Parses the programs under test/, skipping the ones with parse errors
+func programs(t *testing.T) map[string][]Ast.Stmt {
+	t.Helper()
+	output := Error.Output
+	Error.Output = ioutil.Discard
+	defer func() { Error.Output, Error.HadError = output, false }()
+	result := map[string][]Ast.Stmt{}
+	err := filepath.WalkDir(filepath.Join("..", "test"), func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || filepath.Ext(path) != ".lox" {
+			return err
+		}
+		source, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		Error.HadError = false
+		scanner := Scanner.NewScanner(string(source))
+		stmts := Parser.NewParser(scanner.ScanTokens()).Parse()
+		if !Error.HadError {
+			result[path] = stmts
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) == 0 {
+		t.Fatal("no programs under test/")
+	}
+	return result
+}
+
+// parse -> JSON -> decode gives back the same tree, printed the same way by
+// every printer, and encodes to the same document
+func TestRoundTrip(t *testing.T) {
+	for path, stmts := range programs(t) {
+		encoded, err := Marshal(stmts)
+		if err != nil {
+			t.Errorf("%s: %v", path, err)
+			continue
+		}
+		decoded, err := Unmarshal(encoded)
+		if err != nil {
+			t.Errorf("%s: %v", path, err)
+			continue
+		}
+		if original, copy := Printer.IndentedSexpr(stmts), Printer.IndentedSexpr(decoded); original != copy {
+			t.Errorf("%s: s-expression differs after decoding:\n%s\nwant:\n%s", path, copy, original)
+		}
+		if original, copy := Printer.Dot(stmts, nil), Printer.Dot(decoded, nil); original != copy {
+			t.Errorf("%s: digraph differs after decoding", path)
+		}
+		original, _ := Printer.Json(stmts, nil)
+		copy, _ := Printer.Json(decoded, nil)
+		if !bytes.Equal(original, copy) {
+			t.Errorf("%s: printed JSON differs after decoding", path)
+		}
+		if again, _ := Marshal(decoded); !bytes.Equal(encoded, again) {
+			t.Errorf("%s: encoding the decoded tree gives another document", path)
+		}
+	}
+}
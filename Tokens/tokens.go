@@ -9,13 +9,16 @@ const (
 	RIGHT_BRACE   = "RIGHT_BRACE"
 	COMMA         = "COMMA"
 	DOT           = "DOT"
+	ELLIPSIS      = "ELLIPSIS"
 	MINUS         = "MINUS"
 	PLUS          = "PLUS"
 	SEMICOLON     = "SEMICOLON"
 	SLASH         = "SLASH"
 	STAR          = "STAR"
+	PERCENT       = "PERCENT"
 	QUESTION_MARK = "QUESTION_MARK"
 	COLON         = "COLON"
+	ARROW         = "ARROW"
 
 	BANG          = "BANG"
 	BANG_EQUAL    = "BANG_EQUAL"
@@ -26,47 +29,91 @@ const (
 	LESS          = "LESS"
 	LESS_EQAUL    = "LESS_EQUAL"
 
+	// compound assignments and increments
+	PLUS_EQUAL    = "PLUS_EQUAL"
+	MINUS_EQUAL   = "MINUS_EQUAL"
+	STAR_EQUAL    = "STAR_EQUAL"
+	SLASH_EQUAL   = "SLASH_EQUAL"
+	PERCENT_EQUAL = "PERCENT_EQUAL"
+	PLUS_PLUS     = "PLUS_PLUS"
+	MINUS_MINUS   = "MINUS_MINUS"
+
 	IDENTIFIER = "IDENTIFIER"
 	STRING     = "STRING"
 	NUMBER     = "NUMBER"
+	// the part of a string before ${
+	INTERPOLATION = "INTERPOLATION"
 
-	AND    = "AND"
-	CLASS  = "CLASS"
-	ELSE   = "ELSE"
-	FALSE  = "FALSE"
-	FUN    = "FUN"
-	FOR    = "FOR"
-	IF     = "IF"
-	NIL    = "NIL"
-	OR     = "OR"
-	PRINT  = "PRINT"
-	RETURN = "RETURN"
-	SUPER  = "SUPER"
-	THIS   = "THIS"
-	TRUE   = "TRUE"
-	VAR    = "VAR"
-	WHILE  = "WHILE"
+	AND     = "AND"
+	ASYNC   = "ASYNC"
+	AWAIT   = "AWAIT"
+	CASE    = "CASE"
+	CLASS   = "CLASS"
+	CONST   = "CONST"
+	DEFAULT = "DEFAULT"
+	ELSE    = "ELSE"
+	FALSE   = "FALSE"
+	FUN     = "FUN"
+	FOR     = "FOR"
+	IF      = "IF"
+	LET     = "LET"
+	MATCH   = "MATCH"
+	NIL     = "NIL"
+	OR      = "OR"
+	PRINT   = "PRINT"
+	RETURN  = "RETURN"
+	SELECT  = "SELECT"
+	SPAWN   = "SPAWN"
+	SUPER   = "SUPER"
+	THIS    = "THIS"
+	TRUE    = "TRUE"
+	VAR     = "VAR"
+	WHILE   = "WHILE"
+	YIELD   = "YIELD"
 
 	EOF = "EOF"
+	// `//` comments, kept apart from the tokens the parser reads
+	COMMENT = "COMMENT"
 )
 
 var Keywords = map[string]string{
-	"and":    AND,
-	"class":  CLASS,
-	"else":   ELSE,
-	"false":  FALSE,
-	"for":    FOR,
-	"fun":    FUN,
-	"if":     IF,
-	"nil":    NIL,
-	"or":     OR,
-	"print":  PRINT,
-	"return": RETURN,
-	"super":  SUPER,
-	"this":   THIS,
-	"true":   TRUE,
-	"var":    VAR,
-	"while":  WHILE,
+	"and":     AND,
+	"async":   ASYNC,
+	"await":   AWAIT,
+	"case":    CASE,
+	"class":   CLASS,
+	"const":   CONST,
+	"default": DEFAULT,
+	"else":    ELSE,
+	"false":   FALSE,
+	"for":     FOR,
+	"fun":     FUN,
+	"if":      IF,
+	"let":     LET,
+	"match":   MATCH,
+	"nil":     NIL,
+	"or":      OR,
+	"print":   PRINT,
+	"return":  RETURN,
+	"select":  SELECT,
+	"spawn":   SPAWN,
+	"super":   SUPER,
+	"this":    THIS,
+	"true":    TRUE,
+	"var":     VAR,
+	"while":   WHILE,
+	"yield":   YIELD,
+}
+
+// The binary operator each compound assignment and increment applies
+var CompoundOperators = map[string]string{
+	PLUS_EQUAL:    PLUS,
+	MINUS_EQUAL:   MINUS,
+	STAR_EQUAL:    STAR,
+	SLASH_EQUAL:   SLASH,
+	PERCENT_EQUAL: PERCENT,
+	PLUS_PLUS:     PLUS,
+	MINUS_MINUS:   MINUS,
 }
 
 type Token struct {
@@ -74,6 +121,7 @@ type Token struct {
 	Lexeme  string
 	Literal any
 	Line    uint
+	Column  uint
 }
 
 func NewToken(tokenType string, lexeme string, literal any, line uint) *Token {
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/AnshVM/golox/Error"
+	"github.com/AnshVM/golox/Optimizer"
+	"github.com/AnshVM/golox/Printer"
+	"github.com/AnshVM/golox/Resolver"
+)
+
+// golox ast prints the syntax tree of a script after parsing and resolving it
+func astCommand(args []string) int {
+	flags := flag.NewFlagSet("ast", flag.ExitOnError)
+	format := flags.String("format", "sexpr", "output format: sexpr, json or dot")
+	optimized := flags.Bool("optimize", false, "show the tree after constant folding and dead code removal")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: golox ast [flags] script")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 64
+	}
+
+	data, err := ioutil.ReadFile(flags.Arg(0))
+	if err != nil {
+		fmt.Println(Error.CANNOT_READ_FILE)
+		return 66
+	}
+	stmts := parse(string(data))
+	if Error.HadError {
+		return 65
+	}
+	interpreter := newInterpreter()
+	Resolver.NewResolver(interpreter).Resolve(stmts)
+	if *optimized {
+		stmts = Optimizer.Optimize(stmts)
+	}
+
+	switch *format {
+	case "sexpr":
+		fmt.Println(Printer.IndentedSexpr(stmts))
+	case "json":
+		out, err := Printer.Json(stmts, interpreter.Depth)
+		if err != nil {
+			fmt.Println(err)
+			return 70
+		}
+		fmt.Println(string(out))
+	case "dot":
+		fmt.Print(Printer.Dot(stmts, interpreter.Depth))
+	default:
+		flags.Usage()
+		return 64
+	}
+	if Error.HadError {
+		return 65
+	}
+	return 0
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/AnshVM/golox/Checker"
+	"github.com/AnshVM/golox/Error"
+	"github.com/AnshVM/golox/Resolver"
+)
+
+// golox check reports type errors without running the script
+func checkCommand(args []string) int {
+	flags := flag.NewFlagSet("check", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: golox check script")
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 64
+	}
+
+	data, err := ioutil.ReadFile(flags.Arg(0))
+	if err != nil {
+		fmt.Println(Error.CANNOT_READ_FILE)
+		return 66
+	}
+	stmts := parse(string(data))
+	if Error.HadError {
+		return 65
+	}
+	Resolver.NewResolver(newInterpreter()).Resolve(stmts)
+	if Error.HadError {
+		return 65
+	}
+	Checker.NewChecker().Check(stmts)
+	if Error.HadError {
+		return 65
+	}
+	return 0
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AnshVM/golox/Golden"
+)
+
+// Builds golox into a temporary directory so the runner can start it
+func buildGolox(t *testing.T) string {
+	t.Helper()
+	binary := filepath.Join(t.TempDir(), "golox")
+	if output, err := exec.Command("go", "build", "-o", binary, ".").CombinedOutput(); err != nil {
+		t.Fatalf("go build: %v\n%s", err, output)
+	}
+	return binary
+}
+
+func TestGolden(t *testing.T) {
+	binary := buildGolox(t)
+	for _, flags := range [][]string{{}, {"-optimize"}} {
+		flags := flags
+		t.Run(strings.Join(append([]string{"golox"}, flags...), " "), func(t *testing.T) {
+			runner := &Golden.Runner{
+				Interpreter: binary,
+				Timeout:     10 * time.Second,
+				Args:        append([]string{"-virtual-clock"}, flags...),
+			}
+			results, err := runner.RunDir("test")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(results) == 0 {
+				t.Fatal("no test programs found under test/")
+			}
+			for _, result := range results {
+				if !result.Passed() {
+					t.Errorf("%s:\n%s", result.Path, strings.Join(result.Failures, "\n"))
+				}
+			}
+		})
+	}
+}
+
+// The programs under testdata/check are checked, not run: their comments
+// expect the errors of golox check
+func TestCheck(t *testing.T) {
+	runner := &Golden.Runner{Interpreter: buildGolox(t), Timeout: 10 * time.Second, Args: []string{"check"}}
+	results, err := runner.RunDir(filepath.Join("testdata", "check"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, result := range results {
+		if !result.Passed() {
+			t.Errorf("%s:\n%s", result.Path, strings.Join(result.Failures, "\n"))
+		}
+	}
+}
+
+// Runs every program under testdata/coverage and compares its LCOV profile
+// and -cover report with the .lcov and .txt files next to it
+func TestCoverage(t *testing.T) {
+	binary := buildGolox(t)
+	programs, err := filepath.Glob(filepath.Join("testdata", "coverage", "*.lox"))
+	if err != nil || len(programs) == 0 {
+		t.Fatalf("no programs under testdata/coverage: %v", err)
+	}
+	for _, program := range programs {
+		base := strings.TrimSuffix(program, ".lox")
+		profile := filepath.Join(t.TempDir(), "profile.lcov")
+		var report bytes.Buffer
+		cmd := exec.Command(binary, "-virtual-clock", "-cover", "-coverprofile", profile, program)
+		cmd.Stderr = &report
+		if err := cmd.Run(); err != nil {
+			t.Errorf("%s: %v\n%s", program, err, report.String())
+			continue
+		}
+		lcov, err := ioutil.ReadFile(profile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		compareFile(t, base+".lcov", string(lcov))
+		compareFile(t, base+".txt", report.String())
+	}
+}
+
+func compareFile(t *testing.T, path string, actual string) {
+	t.Helper()
+	expected, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual != string(expected) {
+		t.Errorf("%s differs, got:\n%s", path, actual)
+	}
+}
+
+// The runner has to notice every way a program can differ from its expectations
+func TestGoldenFailures(t *testing.T) {
+	binary := buildGolox(t)
+	runner := &Golden.Runner{Interpreter: binary, Timeout: 10 * time.Second}
+	tests := []struct {
+		name    string
+		source  string
+		failure string
+	}{
+		{"output", "print 1; // expect: 2\n", "output differs:\n  1 - 2\n  1 + 1\n"},
+		{"missing output", "// expect: 1\n", "output differs:\n  1 - 1\n"},
+		{"extra output", "print 1;\n", "output differs:\n  1 + 1\n"},
+		{"runtime error", "print 1; // expect runtime error: Boom.\n", `missing runtime error "Boom."`},
+		{"compile error", "print 1; // Error at 'print': Boom.\n", `missing compile error "[line 1] Error at 'print': Boom."`},
+		{"exit code", "print -\"a\";\n", "expected exit code 0, got 70"},
+	}
+	for _, test := range tests {
+		path := filepath.Join(t.TempDir(), "test.lox")
+		if err := ioutil.WriteFile(path, []byte(test.source), 0644); err != nil {
+			t.Fatal(err)
+		}
+		result := runner.RunFile(path)
+		if result.Passed() {
+			t.Errorf("%s: passed, want a failure", test.name)
+			continue
+		}
+		found := false
+		for _, failure := range result.Failures {
+			found = found || strings.HasPrefix(failure, test.failure)
+		}
+		if !found {
+			t.Errorf("%s: failures %q, want %q", test.name, result.Failures, test.failure)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/AnshVM/golox/Error"
+	"github.com/AnshVM/golox/Linter"
+)
+
+type fileDiagnostic struct {
+	File string `json:"file"`
+	Linter.Diagnostic
+}
+
+// golox lint reports suspicious code, exiting with 1 when a rule set to
+// error is broken
+func lintCommand(args []string) int {
+	flags := flag.NewFlagSet("lint", flag.ExitOnError)
+	config := Linter.Config{}
+	flags.Var(config, "rule", "severity of a rule as rule=off|warning|error, can be repeated")
+	format := flags.String("format", "text", "output format: text or json")
+	listRules := flags.Bool("rules", false, "list the rules and their default severity")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: golox lint [flags] script...")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if *listRules {
+		for _, rule := range Linter.Rules() {
+			fmt.Printf("%-24s %-8s %s\n", rule.Name, rule.Severity, rule.Description)
+		}
+		return 0
+	}
+	if flags.NArg() == 0 || (*format != "text" && *format != "json") {
+		flags.Usage()
+		return 64
+	}
+
+	diagnostics := []fileDiagnostic{}
+	for _, path := range flags.Args() {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			fmt.Println(Error.CANNOT_READ_FILE)
+			return 66
+		}
+		stmts := parse(string(data))
+		if Error.HadError {
+			return 65
+		}
+		for _, diagnostic := range Linter.Lint(string(data), stmts, config) {
+			diagnostics = append(diagnostics, fileDiagnostic{File: path, Diagnostic: diagnostic})
+		}
+	}
+
+	if *format == "json" {
+		out, _ := json.MarshalIndent(diagnostics, "", "  ")
+		fmt.Println(string(out))
+	} else {
+		for _, d := range diagnostics {
+			fmt.Printf("%s:%s\n", d.File, d.Diagnostic)
+		}
+	}
+	for _, d := range diagnostics {
+		if d.Severity == Linter.Error {
+			return 1
+		}
+	}
+	return 0
+}
@@ -1,35 +1,85 @@
 package main
 
 import (
-	"bufio"
+	"context"
 	"errors"
+	"flag"
 	"fmt"
 	"io/ioutil"
 	"os"
 
+	"github.com/AnshVM/golox/Ast"
+	"github.com/AnshVM/golox/Coverage"
 	"github.com/AnshVM/golox/Environment"
 	"github.com/AnshVM/golox/Error"
 	"github.com/AnshVM/golox/Interpreter"
+	"github.com/AnshVM/golox/Optimizer"
 	"github.com/AnshVM/golox/Parser"
 	"github.com/AnshVM/golox/Resolver"
 	"github.com/AnshVM/golox/Scanner"
+	"github.com/AnshVM/golox/Serializer"
 )
 
-func run(i *Interpreter.Interpreter, source string) {
+var (
+	maxDepth  = flag.Int("max-depth", Interpreter.DefaultMaxDepth, "maximum call depth, 0 for no limit")
+	maxSteps  = flag.Int("max-steps", 0, "maximum number of executed statements, 0 for no limit")
+	maxAllocs = flag.Int("max-allocs", 0, "approximate maximum number of allocated values, 0 for no limit")
+	timeout   = flag.Duration("timeout", 0, "maximum running time of a program, 0 for no limit")
+	fromJson  = flag.Bool("json", false, "the script is a JSON syntax tree written by golox parse -json")
+	werror    = flag.Bool("Werror", false, "treat warnings as errors")
+	optimize  = flag.Bool("optimize", false, "fold constants and remove dead code before running")
+	cover     = flag.Bool("cover", false, "print how often every line and branch ran to stderr")
+	coverOut  = flag.String("coverprofile", "", "write an LCOV coverage report to this file")
+	virtual   = flag.Bool("virtual-clock", false, "run timers without waiting, as if time jumped to each one")
+)
+
+// records what runs when -cover or -coverprofile is given
+var profile *Coverage.Profile
+
+// subcommands, anything else is run as a script
+var commands = map[string]func(args []string) int{
+	"ast":   astCommand,
+	"check": checkCommand,
+	"lint":  lintCommand,
+	"parse": parseCommand,
+	"run":   runCommand,
+	"test":  testCommand,
+}
+
+func parse(source string) []Ast.Stmt {
 	scanner := Scanner.NewScanner(source)
-	tokens := scanner.ScanTokens()
+	parser := Parser.NewParser(scanner.ScanTokens())
+	return parser.Parse()
+}
 
-	parser := Parser.NewParser(tokens)
-	stmts := parser.Parse()
-	resolver := Resolver.NewResolver(i)
+func run(i *Interpreter.Interpreter, source string) {
+	stmts := parse(source)
 	if Error.HadError {
 		return
 	}
+	runStmts(i, stmts)
+}
+
+func runStmts(i *Interpreter.Interpreter, stmts []Ast.Stmt) {
+	resolver := Resolver.NewResolver(i)
 	resolver.Resolve(stmts)
 	if Error.HadError {
 		return
 	}
-	i.Interpret(stmts)
+	if *optimize {
+		stmts = Optimizer.Optimize(stmts)
+	}
+	if *cover || *coverOut != "" {
+		profile = Coverage.New(stmts)
+		i.Tracer = profile
+	}
+	ctx := context.Background()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+	i.InterpretContext(ctx, stmts)
 }
 
 func runFile(i *Interpreter.Interpreter, path string) error {
@@ -37,7 +87,22 @@ func runFile(i *Interpreter.Interpreter, path string) error {
 	if err != nil {
 		return errors.New(Error.CANNOT_READ_FILE)
 	}
-	run(i, string(data))
+	source := string(data)
+	if *fromJson {
+		stmts, err := Serializer.Unmarshal(data)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(65)
+		}
+		// the tokens point into a file we don't have
+		source = ""
+		runStmts(i, stmts)
+	} else {
+		run(i, source)
+	}
+	if profile != nil {
+		writeCoverage(path, source)
+	}
 	if Error.HadError {
 		os.Exit(65)
 	}
@@ -47,24 +112,77 @@ func runFile(i *Interpreter.Interpreter, path string) error {
 	return nil
 }
 
-func runPrompt(i *Interpreter.Interpreter) {
-	reader := bufio.NewReader(os.Stdin)
-	for {
-		fmt.Print("> ")
-		line, _ := reader.ReadString('\n')
-		line = line[:len(line)-1]
-		run(i, line)
-		Error.HadError = false
-		Error.HadRuntimeError = false
+func writeCoverage(path string, source string) {
+	if *cover {
+		profile.Report(os.Stderr, source)
+	}
+	if *coverOut != "" {
+		file, err := os.Create(*coverOut)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(74)
+		}
+		defer file.Close()
+		profile.LCOV(file, path)
 	}
 }
 
-func main() {
+// golox run [flags] script is the same as golox [flags] script
+func runCommand(args []string) int {
+	flag.CommandLine.Parse(args)
+	if flag.NArg() != 1 {
+		flag.Usage()
+		return 64
+	}
+	Error.WarningsAsErrors = *werror
+	if err := runFile(newInterpreter(), flag.Arg(0)); err != nil {
+		fmt.Println(err)
+		return 66
+	}
+	return 0
+}
+
+// Creates an interpreter with fresh globals and the limits given on the command line
+func newInterpreter() *Interpreter.Interpreter {
 	globals := Environment.Environment{Values: make(map[string]any)}
 	interpreter := Interpreter.NewInterpreter(&globals)
-	if len(os.Args) == 2 {
-		runFile(interpreter, os.Args[1])
-	} else {
+	interpreter.SetLimits(Interpreter.Limits{
+		MaxDepth:  *maxDepth,
+		MaxSteps:  *maxSteps,
+		MaxAllocs: *maxAllocs,
+	})
+	if *virtual {
+		interpreter.SetClock(Interpreter.NewVirtualClock())
+	}
+	return interpreter
+}
+
+func main() {
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			os.Exit(command(os.Args[2:]))
+		}
+	}
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: golox [flags] [script]")
+		fmt.Fprintln(flag.CommandLine.Output(), "       golox run [flags] script")
+		fmt.Fprintln(flag.CommandLine.Output(), "       golox ast [-format sexpr|json|dot] script")
+		fmt.Fprintln(flag.CommandLine.Output(), "       golox check script")
+		fmt.Fprintln(flag.CommandLine.Output(), "       golox lint [-rule name=severity] [-format text|json] script...")
+		fmt.Fprintln(flag.CommandLine.Output(), "       golox parse [-json] script")
+		fmt.Fprintln(flag.CommandLine.Output(), "       golox test [-unit] [-optimize] [-v] [dir or file...]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	Error.WarningsAsErrors = *werror
+	interpreter := newInterpreter()
+	switch flag.NArg() {
+	case 0:
 		runPrompt(interpreter)
+	case 1:
+		runFile(interpreter, flag.Arg(0))
+	default:
+		flag.Usage()
+		os.Exit(64)
 	}
 }
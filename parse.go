@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/AnshVM/golox/Error"
+	"github.com/AnshVM/golox/Serializer"
+)
+
+// golox parse checks the syntax of a script, and with -json prints its tree
+// in the format documented in docs/ast-schema.md
+func parseCommand(args []string) int {
+	flags := flag.NewFlagSet("parse", flag.ExitOnError)
+	asJson := flags.Bool("json", false, "print the syntax tree as versioned JSON")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: golox parse [flags] script")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 64
+	}
+
+	data, err := ioutil.ReadFile(flags.Arg(0))
+	if err != nil {
+		fmt.Println(Error.CANNOT_READ_FILE)
+		return 66
+	}
+	stmts := parse(string(data))
+	if Error.HadError {
+		return 65
+	}
+	if *asJson {
+		out, err := Serializer.Marshal(stmts)
+		if err != nil {
+			fmt.Println(err)
+			return 70
+		}
+		fmt.Println(string(out))
+	}
+	return 0
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/AnshVM/golox/Environment"
+	"github.com/AnshVM/golox/Error"
+	"github.com/AnshVM/golox/Interpreter"
+	"github.com/AnshVM/golox/Parser"
+	"github.com/AnshVM/golox/Printer"
+	"github.com/AnshVM/golox/Scanner"
+	"github.com/peterh/liner"
+)
+
+const historyFile = ".golox_history"
+
+const replHelp = `Commands:
+  :help           show this message
+  :env            print the global and current environment
+  :ast <source>   print the syntax tree of source
+  :tokens <src>   print the tokens of src
+  :load <file>    run a file in the current session
+  :reset          start over with fresh globals
+  :time <source>  run source and print how long it took
+`
+
+type repl struct {
+	interpreter *Interpreter.Interpreter
+	line        *liner.State
+}
+
+func runPrompt(i *Interpreter.Interpreter) {
+	r := &repl{interpreter: i, line: liner.NewLiner()}
+	defer r.line.Close()
+	r.line.SetCtrlCAborts(true)
+
+	history := historyPath()
+	if f, err := os.Open(history); err == nil {
+		r.line.ReadHistory(f)
+		f.Close()
+	}
+	defer saveHistory(r.line, history)
+
+	r.interpreter.Echo = true
+	for {
+		source, err := r.readInput()
+		if err == io.EOF {
+			fmt.Println()
+			return
+		}
+		if err != nil {
+			// Ctrl-C drops the current input
+			continue
+		}
+		if strings.HasPrefix(strings.TrimSpace(source), ":") {
+			r.metaCommand(strings.TrimSpace(source))
+		} else {
+			run(r.interpreter, completeStatement(source))
+		}
+		Error.HadError = false
+		Error.HadRuntimeError = false
+		Error.HadWarning = false
+	}
+}
+
+// Reads lines until brackets, strings and comments are closed
+func (r *repl) readInput() (string, error) {
+	source := ""
+	prompt := "> "
+	for {
+		input, err := r.line.Prompt(prompt)
+		if err != nil {
+			return "", err
+		}
+		source += input + "\n"
+		if !isIncomplete(source) {
+			break
+		}
+		prompt = "... "
+	}
+	if entry := strings.ReplaceAll(strings.TrimSpace(source), "\n", " "); entry != "" {
+		r.line.AppendHistory(entry)
+	}
+	return source, nil
+}
+
+func (r *repl) metaCommand(input string) {
+	name, arg, _ := strings.Cut(input, " ")
+	arg = strings.TrimSpace(arg)
+	switch name {
+	case ":help":
+		fmt.Print(replHelp)
+	case ":env":
+		printEnvironment("globals", r.interpreter.Globals())
+		if r.interpreter.Env != r.interpreter.Globals() {
+			printEnvironment("current", r.interpreter.Env)
+		}
+	case ":ast":
+		scanner := Scanner.NewScanner(completeStatement(arg))
+		stmts := Parser.NewParser(scanner.ScanTokens()).Parse()
+		if !Error.HadError {
+			fmt.Println(Printer.Sexpr(stmts))
+		}
+	case ":tokens":
+		scanner := Scanner.NewScanner(arg)
+		for _, token := range scanner.ScanTokens() {
+			fmt.Println(token.ToString())
+		}
+	case ":load":
+		data, err := ioutil.ReadFile(arg)
+		if err != nil {
+			fmt.Println(Error.CANNOT_READ_FILE)
+			return
+		}
+		run(r.interpreter, string(data))
+	case ":reset":
+		r.interpreter.Close()
+		r.interpreter = newInterpreter()
+		r.interpreter.Echo = true
+	case ":time":
+		start := time.Now()
+		run(r.interpreter, completeStatement(arg))
+		fmt.Printf("took %v\n", time.Since(start))
+	default:
+		fmt.Printf("Unknown command %s, try :help\n", name)
+	}
+}
+
+func printEnvironment(title string, env *Environment.Environment) {
+	names := []string{}
+	for name := range env.Values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Printf("%s:\n", title)
+	for _, name := range names {
+		fmt.Printf("  %s = %s\n", name, Interpreter.Stringify(env.Values[name]))
+	}
+}
+
+func historyPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, historyFile)
+}
+
+func saveHistory(line *liner.State, path string) {
+	if path == "" {
+		return
+	}
+	if f, err := os.Create(path); err == nil {
+		line.WriteHistory(f)
+		f.Close()
+	}
+}
+
+// Walks the source skipping strings and comments, returning the bracket depth
+// and whether a string or comment is left open
+func inspectInput(source string) (depth int, open bool) {
+	for c := 0; c < len(source); c++ {
+		switch {
+		case source[c] == '"':
+			end := stringEnd(source, c+1)
+			if end == -1 {
+				return depth, true
+			}
+			c = end
+		case strings.HasPrefix(source[c:], "//"):
+			end := strings.IndexByte(source[c:], '\n')
+			if end == -1 {
+				return depth, false
+			}
+			c += end
+			continue
+		case strings.HasPrefix(source[c:], "/*"):
+			end := strings.Index(source[c+2:], "*/")
+			if end == -1 {
+				return depth, true
+			}
+			c += end + 3
+			continue
+		case source[c] == '(' || source[c] == '{':
+			depth++
+		case source[c] == ')' || source[c] == '}':
+			depth--
+		}
+	}
+	return depth, false
+}
+
+// Returns the index of the '"' that closes the string starting at start,
+// skipping over the code in ${...}, or -1 when the string isn't closed yet
+func stringEnd(source string, start int) int {
+	for c := start; c < len(source); c++ {
+		if source[c] == '"' {
+			return c
+		}
+		if source[c] == '\\' {
+			c++
+			continue
+		}
+		if !strings.HasPrefix(source[c:], "${") {
+			continue
+		}
+		depth := 0
+		for c += 2; c < len(source) && !(source[c] == '}' && depth == 0); c++ {
+			switch source[c] {
+			case '"':
+				c = stringEnd(source, c+1)
+				if c == -1 {
+					return -1
+				}
+			case '{':
+				depth++
+			case '}':
+				depth--
+			}
+		}
+	}
+	return -1
+}
+
+func isIncomplete(source string) bool {
+	depth, open := inspectInput(source)
+	return depth > 0 || open
+}
+
+// Lets a statement be typed without the trailing ';'. It is only added when
+// the source doesn't parse as it is but does with it, so the errors of
+// source that parses neither way are the ones the user typed
+func completeStatement(source string) string {
+	if strings.TrimSpace(source) == "" || parses(source) || !parses(source+";") {
+		return source
+	}
+	return source + ";"
+}
+
+// Parses source without reporting its errors
+func parses(source string) bool {
+	output, hadError := Error.Output, Error.HadError
+	Error.Output, Error.HadError = ioutil.Discard, false
+	defer func() { Error.Output, Error.HadError = output, hadError }()
+	parse(source)
+	return !Error.HadError
+}
@@ -0,0 +1,67 @@
+package main
+
+import "testing"
+
+func TestStringEnd(t *testing.T) {
+	tests := []struct {
+		source string
+		start  int
+		end    int
+	}{
+		{`"abc"`, 1, 4},
+		{`"a\"b"`, 1, 5},
+		{`"a${"}"}b"`, 1, 9},
+		{`"a${f({})}b"`, 1, 11},
+		{`"abc`, 1, -1},
+		{`"a${"b}`, 1, -1},
+	}
+	for _, test := range tests {
+		if end := stringEnd(test.source, test.start); end != test.end {
+			t.Errorf("stringEnd(%q, %d) = %d, want %d", test.source, test.start, end, test.end)
+		}
+	}
+}
+
+func TestIsIncomplete(t *testing.T) {
+	tests := map[string]bool{
+		"print 1;\n":               false,
+		"fun f() {\n":              true,
+		"fun f() {\n}\n":           false,
+		"print (1 +\n":             true,
+		"print \"a\n":              true,
+		"print \"(\";\n":           false,
+		"print 1; // {\n":          false,
+		"/* {\n":                   true,
+		"/* { */ print 1;\n":       false,
+		"print \"${f(\"}\")}\";\n": false,
+		"var f = fun() {\n":        true,
+		"var f = fun() {}\n":       false,
+		"print \"${\n":             true,
+		"{ print \"}\"; \n":        true,
+		"if (a) {\n} else {\n}\n":  false,
+	}
+	for source, incomplete := range tests {
+		if isIncomplete(source) != incomplete {
+			t.Errorf("isIncomplete(%q) = %v, want %v", source, !incomplete, incomplete)
+		}
+	}
+}
+
+func TestCompleteStatement(t *testing.T) {
+	tests := map[string]string{
+		"1 + 2\n":             "1 + 2\n;",
+		"print 1;\n":          "print 1;\n",
+		"var f = fun(){}\n":   "var f = fun(){}\n;",
+		"fun f() {}\n":        "fun f() {}\n",
+		"{ print 1; }\n":      "{ print 1; }\n",
+		"if (true) print 1\n": "if (true) print 1\n;",
+		"print 1 // note\n":   "print 1 // note\n;",
+		"print 1 +\n":         "print 1 +\n",
+		"\n":                  "\n",
+	}
+	for source, expected := range tests {
+		if got := completeStatement(source); got != expected {
+			t.Errorf("completeStatement(%q) = %q, want %q", source, got, expected)
+		}
+	}
+}
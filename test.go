@@ -0,0 +1,265 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/AnshVM/golox/Ast"
+	"github.com/AnshVM/golox/Error"
+	"github.com/AnshVM/golox/Golden"
+	"github.com/AnshVM/golox/Interpreter"
+	"github.com/AnshVM/golox/Optimizer"
+	"github.com/AnshVM/golox/Parser"
+	"github.com/AnshVM/golox/Resolver"
+	"github.com/AnshVM/golox/Scanner"
+	"github.com/AnshVM/golox/Tokens"
+)
+
+// golox test runs .lox programs and compares what they do with the
+// `// expect: ...` comments in their source. With -unit it runs the
+// `test "name" { ... }` blocks in them instead
+func testCommand(args []string) int {
+	flags := flag.NewFlagSet("test", flag.ExitOnError)
+	optimized := flags.Bool("optimize", false, "run the programs with -optimize")
+	unit := flags.Bool("unit", false, "run the test blocks declared in the programs")
+	verbose := flags.Bool("v", false, "list passing programs, or show the output of passing tests with -unit")
+	testTimeout := flags.Duration("timeout", 10*time.Second, "time limit for each program")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: golox test [flags] [dir or file...]")
+		fmt.Fprintln(flags.Output(), "       golox test -unit [flags] [dir or file...]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"test"}
+	}
+	if *unit {
+		return unitTests(paths, *optimized, *verbose, *testTimeout)
+	}
+
+	binary, err := os.Executable()
+	if err != nil {
+		fmt.Println(err)
+		return 70
+	}
+	// timers fire at once and in a fixed order, so expectations hold
+	runner := &Golden.Runner{Interpreter: binary, Timeout: *testTimeout, Args: []string{"-virtual-clock"}}
+	if *optimized {
+		runner.Args = append(runner.Args, "-optimize")
+	}
+
+	results := []Golden.Result{}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			fmt.Println(err)
+			return 66
+		}
+		if !info.IsDir() {
+			results = append(results, runner.RunFile(path))
+			continue
+		}
+		dirResults, err := runner.RunDir(path)
+		if err != nil {
+			fmt.Println(err)
+			return 66
+		}
+		results = append(results, dirResults...)
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.Passed() {
+			if *verbose {
+				fmt.Printf("PASS %s\n", result.Path)
+			}
+			continue
+		}
+		failed++
+		fmt.Printf("FAIL %s\n", result.Path)
+		for _, failure := range result.Failures {
+			fmt.Printf("  %s\n", failure)
+		}
+	}
+	fmt.Printf("%d passed, %d failed\n", len(results)-failed, failed)
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+type unitResult struct {
+	path   string
+	name   string
+	passed bool
+	output string // what the program printed, followed by its errors
+}
+
+// Runs every test block on its own: the program is parsed and run again
+// with fresh globals before each test, so tests can't see what other tests did
+func unitTests(paths []string, optimized bool, verbose bool, testTimeout time.Duration) int {
+	files, err := loxFiles(paths)
+	if err != nil {
+		fmt.Println(err)
+		return 66
+	}
+	results := []unitResult{}
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Println(err)
+			return 66
+		}
+		source := string(data)
+		var staticErrors bytes.Buffer
+		Error.Output = &staticErrors
+		scanner := Scanner.NewScanner(source)
+		tokens := scanner.ScanTokens()
+		stmts := Parser.NewParser(tokens).Parse()
+		if !declaresTests(tokens) {
+			Error.Output = os.Stderr
+			Error.HadError = false
+			continue
+		}
+		if !Error.HadError {
+			// resolving reports the errors the parser lets through, like a
+			// test block that isn't at the top level
+			Resolver.NewResolver(newInterpreter()).Resolve(stmts)
+		}
+		Error.Output = os.Stderr
+		if Error.HadError {
+			// a file that doesn't parse or resolve counts as one failed test
+			Error.HadError = false
+			results = append(results, unitResult{path: path, output: staticErrors.String()})
+			continue
+		}
+		tests := 0
+		for _, stmt := range stmts {
+			if _, ok := stmt.(*Ast.TestStmt); ok {
+				results = append(results, runUnitTest(path, source, tests, optimized, testTimeout))
+				tests++
+			}
+		}
+	}
+
+	failed := 0
+	for _, result := range results {
+		name := result.path
+		if result.name != "" {
+			name = fmt.Sprintf("%s %q", result.path, result.name)
+		}
+		if result.passed {
+			fmt.Printf("PASS %s\n", name)
+			if verbose {
+				fmt.Print(indentOutput(result.output))
+			}
+			continue
+		}
+		failed++
+		fmt.Printf("FAIL %s\n", name)
+		fmt.Print(indentOutput(result.output))
+	}
+	fmt.Printf("%d passed, %d failed\n", len(results)-failed, failed)
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// Runs the index-th test block of source after the rest of the program
+func runUnitTest(path string, source string, index int, optimized bool, testTimeout time.Duration) unitResult {
+	var output bytes.Buffer
+	Error.Output = &output
+	Error.HadError = false
+	Error.HadRuntimeError = false
+	defer func() {
+		Error.Output = os.Stderr
+		Error.HadError = false
+		Error.HadRuntimeError = false
+	}()
+
+	stmts := parse(source)
+	tests := []*Ast.TestStmt{}
+	for _, stmt := range stmts {
+		if test, ok := stmt.(*Ast.TestStmt); ok {
+			tests = append(tests, test)
+		}
+	}
+	test := tests[index]
+	result := unitResult{path: path, name: test.Name.Literal.(string)}
+
+	interpreter := newInterpreter()
+	defer interpreter.Close()
+	interpreter.Out = &output
+	interpreter.SetClock(Interpreter.NewVirtualClock())
+	resolver := Resolver.NewResolver(interpreter)
+	resolver.Resolve(stmts)
+	if Error.HadError {
+		result.output = output.String()
+		return result
+	}
+	if optimized {
+		stmts = Optimizer.Optimize(stmts)
+	}
+	ctx := context.Background()
+	if testTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, testTimeout)
+		defer cancel()
+	}
+	err := interpreter.InterpretContext(ctx, stmts)
+	if err == nil {
+		err = interpreter.RunTest(ctx, test)
+	}
+	result.passed = err == nil && !Error.HadRuntimeError
+	result.output = output.String()
+	return result
+}
+
+// Whether a `test` is followed by a name, which is what the parser takes
+// for a test block
+func declaresTests(tokens []*Tokens.Token) bool {
+	for index, token := range tokens[:len(tokens)-1] {
+		if token.Type == Tokens.IDENTIFIER && token.Lexeme == "test" && tokens[index+1].Type == Tokens.STRING {
+			return true
+		}
+	}
+	return false
+}
+
+// The .lox files given on the command line and in the given directories
+func loxFiles(paths []string) ([]string, error) {
+	files := []string{}
+	for _, path := range paths {
+		err := filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !entry.IsDir() && (file == path || filepath.Ext(file) == ".lox") {
+				files = append(files, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func indentOutput(output string) string {
+	if output == "" {
+		return ""
+	}
+	return "    " + strings.ReplaceAll(strings.TrimSuffix(output, "\n"), "\n", "\n    ") + "\n"
+}